@@ -1,15 +1,19 @@
 package cmd
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"syscall"
+	"time"
 
 	"github.com/USA-RedDragon/metrics-actioner/internal/alertmanager"
 	"github.com/USA-RedDragon/metrics-actioner/internal/config"
+	"github.com/USA-RedDragon/metrics-actioner/internal/history"
 	"github.com/USA-RedDragon/metrics-actioner/internal/server"
+	"github.com/USA-RedDragon/metrics-actioner/internal/tracing"
 	"github.com/spf13/cobra"
 	"github.com/ztrue/shutdown"
 	"golang.org/x/sync/errgroup"
@@ -32,6 +36,8 @@ func NewCommand(version, commit string) *cobra.Command {
 		SilenceErrors: true,
 	}
 	config.RegisterFlags(cmd)
+	cmd.AddCommand(newValidateCommand())
+	cmd.AddCommand(newTestCommand())
 	return cmd
 }
 
@@ -42,18 +48,45 @@ func run(cmd *cobra.Command, _ []string) error {
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
+	if err := config.CheckFiles(); err != nil {
+		return fmt.Errorf("failed to check config files: %w", err)
+	}
+
+	shutdownTracing := func(context.Context) error { return nil }
+	if config.HTTP.Tracing.Enabled && config.HTTP.Tracing.OTLPEndpoint != "" {
+		shutdownTracing, err = tracing.Setup(cmd.Context(), &config.HTTP.Tracing, cmd.Annotations["version"])
+		if err != nil {
+			return fmt.Errorf("failed to set up tracing: %w", err)
+		}
+	}
 
-	alertmanagerReceiver := alertmanager.NewReceiver(&config.Actions)
+	historyStore, err := history.NewStore(&config.History)
+	if err != nil {
+		return fmt.Errorf("failed to open execution history: %w", err)
+	}
+
+	alertmanagerReceiver, err := alertmanager.NewReceiver(config, historyStore)
+	if err != nil {
+		return fmt.Errorf("failed to create AlertManager receiver: %w", err)
+	}
+	alertmanagerReceiver.Start()
 
 	slog.Info("Starting HTTP server")
-	server := server.NewServer(&config.HTTP, alertmanagerReceiver)
+	server, err := server.NewServer(&config.HTTP, alertmanagerReceiver, historyStore)
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP server: %w", err)
+	}
 	err = server.Start()
 	if err != nil {
 		return fmt.Errorf("failed to start HTTP server: %w", err)
 	}
 
+	reloadCtx, stopReloading := context.WithCancel(context.Background())
+	newReloader(cmd, alertmanagerReceiver, config).start(reloadCtx)
+
 	stop := func(sig os.Signal) {
 		slog.Info("Shutting down")
+		stopReloading()
 
 		errGrp := errgroup.Group{}
 
@@ -68,6 +101,21 @@ func run(cmd *cobra.Command, _ []string) error {
 			slog.Error("Shutdown error", "error", err.Error())
 			os.Exit(1)
 		}
+
+		// Finish the queued actions once no more webhooks can arrive
+		ctx, cancel := context.WithTimeout(context.Background(), time.Duration(config.Workers.DrainTimeout))
+		defer cancel()
+		alertmanagerReceiver.Stop(ctx)
+
+		if err := historyStore.Close(); err != nil {
+			slog.Error("Failed to close execution history", "error", err.Error())
+		}
+
+		flushCtx, flushCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer flushCancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			slog.Error("Failed to flush traces", "error", err.Error())
+		}
 		slog.Info("Shutdown complete")
 	}
 
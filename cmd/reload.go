@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"log/slog"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/USA-RedDragon/metrics-actioner/internal/alertmanager"
+	"github.com/USA-RedDragon/metrics-actioner/internal/config"
+	"github.com/USA-RedDragon/metrics-actioner/internal/metrics"
+	"github.com/spf13/cobra"
+)
+
+// reloader reloads the rules on SIGHUP and, when enabled, whenever the
+// config file changes. Only the rules and dry run setting are reloaded,
+// other settings need a restart.
+type reloader struct {
+	cmd      *cobra.Command
+	receiver *alertmanager.Receiver
+	mu       sync.Mutex
+	config   *config.Config
+	checksum [sha256.Size]byte
+}
+
+func newReloader(cmd *cobra.Command, receiver *alertmanager.Receiver, config *config.Config) *reloader {
+	rl := &reloader{
+		cmd:      cmd,
+		receiver: receiver,
+		config:   config,
+	}
+	rl.checksum, _ = rl.configChecksum()
+	metrics.ConfigLastReloadSuccessful.Set(1)
+	metrics.ConfigLastReloadSuccess.SetToCurrentTime()
+	return rl
+}
+
+// start watches for reload triggers until ctx is done
+func (rl *reloader) start(ctx context.Context) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	var ticker *time.Ticker
+	var tick <-chan time.Time
+	if rl.config.Reload.Watch {
+		configPath, _ := rl.cmd.Flags().GetString(config.ConfigFileKey)
+		if configPath == "" {
+			slog.Warn("Not watching the config for changes, no config file given")
+		} else {
+			ticker = time.NewTicker(time.Duration(rl.config.Reload.Interval))
+			tick = ticker.C
+			slog.Info("Watching the config for changes", "file", configPath, "interval", rl.config.Reload.Interval)
+		}
+	}
+
+	go func() {
+		defer signal.Stop(signals)
+		if ticker != nil {
+			defer ticker.Stop()
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-signals:
+				rl.reload("signal")
+			case <-tick:
+				checksum, err := rl.configChecksum()
+				if err != nil {
+					slog.Warn("Failed to check the config for changes", "error", err.Error())
+					continue
+				}
+				if checksum != rl.checksum {
+					rl.reload("file")
+				}
+			}
+		}
+	}()
+}
+
+func (rl *reloader) configChecksum() ([sha256.Size]byte, error) {
+	configPath, err := rl.cmd.Flags().GetString(config.ConfigFileKey)
+	if err != nil || configPath == "" {
+		return [sha256.Size]byte{}, err
+	}
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(data), nil
+}
+
+// reload loads and validates the config and swaps the receiver's rules,
+// keeping the current ones if anything fails
+func (rl *reloader) reload(trigger string) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	// Remember the file's contents even if it's invalid, so a broken file
+	// is reported once rather than on every check
+	if checksum, err := rl.configChecksum(); err == nil {
+		rl.checksum = checksum
+	}
+
+	newConfig, err := config.LoadConfig(rl.cmd)
+	if err == nil {
+		err = rl.receiver.Reload(newConfig)
+	}
+	if err != nil {
+		slog.Error("Failed to reload config, keeping the current config", "trigger", trigger, "error", err.Error())
+		metrics.ConfigReloads.WithLabelValues("failure").Inc()
+		metrics.ConfigLastReloadSuccessful.Set(0)
+		return
+	}
+
+	if restartRequired(rl.config, newConfig) {
+		slog.Warn("Config changes other than actions and dry_run need a restart to take effect")
+	}
+	rl.config = newConfig
+	slog.Info("Reloaded config", "trigger", trigger, "rules", len(newConfig.Actions), "dryRun", newConfig.DryRun)
+	metrics.ConfigReloads.WithLabelValues("success").Inc()
+	metrics.ConfigLastReloadSuccessful.Set(1)
+	metrics.ConfigLastReloadSuccess.SetToCurrentTime()
+}
+
+// restartRequired reports whether settings that are only read on startup changed
+func restartRequired(current, updated *config.Config) bool {
+	a, b := *current, *updated
+	a.Actions, b.Actions = nil, nil
+	a.DryRun, b.DryRun = false, false
+	return !reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/USA-RedDragon/metrics-actioner/internal/alertmanager"
+	"github.com/USA-RedDragon/metrics-actioner/internal/alertmanager/models"
+	"github.com/USA-RedDragon/metrics-actioner/internal/config"
+	"github.com/USA-RedDragon/metrics-actioner/internal/history"
+	"github.com/spf13/cobra"
+)
+
+const webhookFlag = "webhook"
+
+func newTestCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "test",
+		Short: "Match a webhook payload against the rules without executing any action",
+		Long: `Match an AlertManager webhook payload against the rules like the server would,
+printing the rules that match with their rendered options and why the others don't.
+No action is executed. Cooldowns, execution limits and deduplication are not applied.`,
+		Args:          cobra.NoArgs,
+		RunE:          runTest,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	config.RegisterFlags(cmd)
+	cmd.Flags().StringP(webhookFlag, "w", "", "Webhook payload JSON file, - reads from stdin")
+	_ = cmd.MarkFlagRequired(webhookFlag)
+	return cmd
+}
+
+func runTest(cmd *cobra.Command, _ []string) error {
+	cfg, err := config.LoadConfig(cmd)
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	webhookPath, err := cmd.Flags().GetString(webhookFlag)
+	if err != nil {
+		return fmt.Errorf("failed to get webhook path: %w", err)
+	}
+	var data []byte
+	if webhookPath == "-" {
+		data, err = io.ReadAll(cmd.InOrStdin())
+	} else {
+		data, err = os.ReadFile(webhookPath)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read webhook: %w", err)
+	}
+	var webhook models.Webhook
+	if err := json.Unmarshal(data, &webhook); err != nil {
+		return fmt.Errorf("failed to parse webhook: %w", err)
+	}
+
+	// The receiver is never started, so nothing is executed or recorded
+	receiver, err := alertmanager.NewReceiver(cfg, history.NewMemoryStore(0))
+	if err != nil {
+		return fmt.Errorf("failed to create AlertManager receiver: %w", err)
+	}
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "Webhook: status=%s receiver=%s alerts=%d\n", webhook.Status, webhook.Receiver, len(webhook.Alerts))
+	matched := 0
+	for _, evaluation := range receiver.Evaluate(&webhook) {
+		if len(evaluation.Matches) > 0 {
+			matched++
+		}
+		printEvaluation(out, &evaluation)
+	}
+	fmt.Fprintf(out, "\n%d of %d rules matched\n", matched, len(cfg.Actions))
+	return nil
+}
+
+func printEvaluation(out io.Writer, evaluation *alertmanager.RuleEvaluation) {
+	result := "NO MATCH"
+	if len(evaluation.Matches) > 0 {
+		result = "MATCH"
+	}
+	dryRun := ""
+	if evaluation.DryRun {
+		dryRun = ", dry run"
+	}
+	fmt.Fprintf(out, "\n%s %s (%s%s)\n", result, evaluation.Rule, evaluation.Action, dryRun)
+
+	for _, match := range evaluation.Matches {
+		fmt.Fprintf(out, "  matched %s\n", describeAlert(match.Alert))
+		if match.Error != nil {
+			fmt.Fprintf(out, "    failed to render options: %s\n", match.Error.Error())
+			continue
+		}
+		for _, key := range slices.Sorted(maps.Keys(match.Options)) {
+			fmt.Fprintf(out, "    %s: %s\n", key, match.Options[key])
+		}
+	}
+	for _, mismatch := range evaluation.Mismatches {
+		fmt.Fprintf(out, "  not matched %s: %s\n", describeAlert(mismatch.Alert), mismatch.Reason)
+	}
+}
+
+func describeAlert(alert *models.Alert) string {
+	if alert == nil {
+		return "group"
+	}
+	labels := make([]string, 0, len(alert.Labels))
+	for _, key := range slices.Sorted(maps.Keys(alert.Labels)) {
+		labels = append(labels, fmt.Sprintf("%s=%q", key, alert.Labels[key]))
+	}
+	return fmt.Sprintf("alert {%s}", strings.Join(labels, ", "))
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/USA-RedDragon/metrics-actioner/internal/alertmanager"
+	"github.com/USA-RedDragon/metrics-actioner/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ErrInvalidConfig = errors.New("invalid configuration")
+)
+
+const checkFilesKey = "check-files"
+
+func newValidateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:           "validate",
+		Short:         "Validate the configuration and exit",
+		Args:          cobra.NoArgs,
+		RunE:          runValidate,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	config.RegisterFlags(cmd)
+	cmd.Flags().Bool(checkFilesKey, false, "Also check the files the config refers to, like secrets and certificates, can be read")
+	return cmd
+}
+
+func runValidate(cmd *cobra.Command, _ []string) error {
+	cfg, err := config.LoadUnvalidatedConfig(cmd)
+	if err == nil {
+		err = errors.Join(cfg.Validate(), alertmanager.ValidateConfig(cfg))
+		checkFiles, flagErr := cmd.Flags().GetBool(checkFilesKey)
+		if flagErr != nil {
+			return fmt.Errorf("failed to get check files flag: %w", flagErr)
+		}
+		if checkFiles {
+			err = errors.Join(err, cfg.CheckFiles())
+		}
+	}
+	if err != nil {
+		fmt.Fprintln(cmd.ErrOrStderr(), err.Error())
+		return ErrInvalidConfig
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), "Configuration is valid")
+	return nil
+}
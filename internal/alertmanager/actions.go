@@ -1,26 +1,56 @@
 package alertmanager
 
 import (
+	"context"
+	"errors"
 	"fmt"
 
 	"github.com/USA-RedDragon/metrics-actioner/internal/alertmanager/actions"
-	"github.com/USA-RedDragon/metrics-actioner/internal/alertmanager/models"
 )
 
+var ErrActionNotFound = errors.New("action not found")
+
 type ActionIface interface {
-	Execute(webhook *models.Webhook, options map[string]string) error
+	// Execute runs the action. Implementations must abort when ctx is done
+	// and must not change anything in a dry run.
+	Execute(ctx context.Context, req *actions.Request) error
+	// OptionSchema describes the options the action accepts
+	OptionSchema() actions.Schema
+}
+
+// TargetedActionIface is implemented by actions that default options to the
+// alert's labels, e.g. the namespace, so cooldowns apply to what the action
+// acts on rather than to the rendered options alone
+type TargetedActionIface interface {
+	ActionIface
+	// Target returns the options with the defaults the action would take
+	// from the request's labels filled in
+	Target(req *actions.Request) map[string]string
+}
+
+// ReversibleActionIface is implemented by actions that can undo their changes
+type ReversibleActionIface interface {
+	ActionIface
+	// Revert restores the state saved in req.State by a previous Execute.
+	// Like Execute, it must not change anything in a dry run.
+	Revert(ctx context.Context, req *actions.Request) error
 }
 
 func (r *Receiver) FindAction(action string) (ActionIface, error) {
 	if action, ok := r.registeredActions[action]; ok {
 		return action, nil
 	}
-	return nil, fmt.Errorf("action not found: %s", action)
+	return nil, fmt.Errorf("%w: %s", ErrActionNotFound, action)
 }
 
 func findActions() map[string]ActionIface {
 	foundActions := make(map[string]ActionIface)
-	foundActions["rollout-restart-deployment"] = &actions.RolloutRestartDeployment{}
+	foundActions["rollout-restart-deployment"] = &actions.RolloutRestart{Kind: actions.WorkloadKindDeployment}
+	foundActions["rollout-restart-statefulset"] = &actions.RolloutRestart{Kind: actions.WorkloadKindStatefulSet}
+	foundActions["rollout-restart-daemonset"] = &actions.RolloutRestart{Kind: actions.WorkloadKindDaemonSet}
+	foundActions["delete-pod"] = &actions.DeletePod{}
+	foundActions["evict-pod"] = &actions.EvictPod{}
+	foundActions["scale"] = &actions.Scale{}
 	foundActions["ssh"] = &actions.SSH{}
 	return foundActions
 }
@@ -0,0 +1,250 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/USA-RedDragon/metrics-actioner/internal/k8s"
+	"github.com/USA-RedDragon/metrics-actioner/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DeletePod deletes the pod named by the pod option, or the alert's pod
+// label, or the pods matching the selector option. The grace_period option
+// overrides the pods' termination grace period, in seconds. It refuses to
+// delete pods when more than max_unavailable of a workload's pods, a number
+// or a percentage rounded up like a PodDisruptionBudget's, would be
+// terminating at once. Pods that aren't managed by a controller aren't limited.
+type DeletePod struct {
+}
+
+type DeletePodOptions struct {
+	PodTarget
+	GracePeriod    *int64
+	MaxUnavailable intstr.IntOrString
+}
+
+// defaultMaxUnavailable only lets one pod of a workload terminate at a time
+const defaultMaxUnavailable = 1
+
+func parseGracePeriod(s string) (int64, error) {
+	seconds, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, fmt.Errorf("invalid grace period %q: must be a non-negative number of seconds", s)
+	}
+	return seconds, nil
+}
+
+// parseMaxUnavailable parses a number of pods like `2` or a percentage like `25%`
+func parseMaxUnavailable(s string) (intstr.IntOrString, error) {
+	invalid := fmt.Errorf("invalid max unavailable %q: must be a number of pods or a percentage", s)
+	value := intstr.Parse(s)
+	if value.Type == intstr.Int {
+		if value.IntVal < 0 {
+			return intstr.IntOrString{}, invalid
+		}
+		return value, nil
+	}
+	percent, err := strconv.ParseInt(strings.TrimSuffix(s, "%"), 10, 32)
+	if !strings.HasSuffix(s, "%") || err != nil || percent < 0 || percent > 100 {
+		return intstr.IntOrString{}, invalid
+	}
+	return value, nil
+}
+
+func (d *DeletePod) OptionSchema() Schema {
+	return Schema{
+		Options: []Option{
+			{Name: "namespace"},
+			{Name: "pod"},
+			{Name: "selector", Validate: validateSelector},
+			{Name: "grace_period", Validate: func(value string) error {
+				_, err := parseGracePeriod(value)
+				return err
+			}},
+			{Name: "max_unavailable", Validate: func(value string) error {
+				_, err := parseMaxUnavailable(value)
+				return err
+			}},
+		},
+		Check: checkPodTarget,
+	}
+}
+
+// Target returns the options with the pod and namespace defaulted to the alert's
+func (d *DeletePod) Target(req *Request) map[string]string {
+	return podTargetOptions(req)
+}
+
+func (d *DeletePod) Execute(ctx context.Context, req *Request) error {
+	slog.Info("DeletePod action executed")
+	opts := DeletePodOptions{MaxUnavailable: intstr.FromInt32(defaultMaxUnavailable)}
+	// Get the options
+	for k, v := range req.Options {
+		switch k {
+		case "namespace":
+			opts.Namespace = v
+		case "pod":
+			opts.Pod = v
+		case "selector":
+			opts.Selector = v
+		case "grace_period":
+			if v == "" {
+				continue
+			}
+			gracePeriod, err := parseGracePeriod(v)
+			if err != nil {
+				return err
+			}
+			opts.GracePeriod = &gracePeriod
+		case "max_unavailable":
+			if v == "" {
+				continue
+			}
+			maxUnavailable, err := parseMaxUnavailable(v)
+			if err != nil {
+				return err
+			}
+			opts.MaxUnavailable = maxUnavailable
+		default:
+			slog.Warn("Unknown option", "option", k)
+		}
+	}
+	// Validate the options
+	if err := opts.resolve(req); err != nil {
+		return err
+	}
+
+	return d.delete(ctx, opts, req)
+}
+
+func (d *DeletePod) delete(ctx context.Context, opts DeletePodOptions, req *Request) error {
+	kubeconfig, err := k8s.GetConfig()
+	if err != nil {
+		return err
+	}
+
+	// Create the clientset
+	clientset, err := kubernetes.NewForConfig(kubeconfig)
+	if err != nil {
+		return err
+	}
+
+	ctx, span := tracing.Tracer("actions").Start(ctx, "k8s.DeletePods", trace.WithAttributes(
+		attribute.String("k8s.namespace.name", opts.Namespace),
+		attribute.String("k8s.pod.name", opts.Pod),
+		attribute.String("k8s.pod.selector", opts.Selector),
+	))
+	defer span.End()
+
+	pods, err := opts.find(ctx, clientset)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+	if err := checkMaxUnavailable(ctx, clientset, opts.Namespace, pods, opts.MaxUnavailable); err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+	span.SetAttributes(attribute.Int("k8s.pods", len(pods)))
+
+	deleteOptions := v1.DeleteOptions{GracePeriodSeconds: opts.GracePeriod}
+	gracePeriod := "the pod's grace period"
+	if opts.GracePeriod != nil {
+		gracePeriod = fmt.Sprintf("a grace period of %ds", *opts.GracePeriod)
+	}
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil {
+			fmt.Fprintf(req.Output, "pod %s/%s is already terminating\n", pod.Namespace, pod.Name)
+			continue
+		}
+		if req.DryRun {
+			slog.Info("Dry run: would delete pod", "namespace", pod.Namespace, "name", pod.Name, "gracePeriodSeconds", opts.GracePeriod)
+			fmt.Fprintf(req.Output, "dry run: would delete pod %s/%s with %s\n", pod.Namespace, pod.Name, gracePeriod)
+			continue
+		}
+
+		slog.Info("Deleting pod", "namespace", pod.Namespace, "name", pod.Name, "gracePeriodSeconds", opts.GracePeriod)
+		// The UID precondition keeps a replacement pod with the same name from being deleted
+		deleteOptions.Preconditions = v1.NewUIDPreconditions(string(pod.UID))
+		err := clientset.CoreV1().Pods(pod.Namespace).Delete(ctx, pod.Name, deleteOptions)
+		if apierrors.IsNotFound(err) {
+			fmt.Fprintf(req.Output, "pod %s/%s was already deleted\n", pod.Namespace, pod.Name)
+			continue
+		}
+		if err != nil {
+			tracing.RecordError(span, err)
+			return err
+		}
+		fmt.Fprintf(req.Output, "pod %s/%s deleted with %s\n", pod.Namespace, pod.Name, gracePeriod)
+	}
+
+	return nil
+}
+
+// checkMaxUnavailable returns an error if deleting the pods would leave more
+// than maxUnavailable of a workload's pods terminating at once
+func checkMaxUnavailable(ctx context.Context, clientset kubernetes.Interface, namespace string, pods []corev1.Pod, maxUnavailable intstr.IntOrString) error {
+	resolver := newWorkloadResolver(clientset, namespace)
+	deleting := make(map[string]int)
+	for i := range pods {
+		if pods[i].DeletionTimestamp != nil {
+			// Already counted as terminating
+			continue
+		}
+		workload, err := resolver.workload(ctx, &pods[i])
+		if err != nil {
+			return err
+		}
+		if workload != "" {
+			deleting[workload]++
+		}
+	}
+	if len(deleting) == 0 {
+		return nil
+	}
+
+	all, err := clientset.CoreV1().Pods(namespace).List(ctx, v1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	total := make(map[string]int)
+	terminating := make(map[string]int)
+	for i := range all.Items {
+		workload, err := resolver.workload(ctx, &all.Items[i])
+		if err != nil {
+			return err
+		}
+		if _, ok := deleting[workload]; !ok {
+			continue
+		}
+		total[workload]++
+		if all.Items[i].DeletionTimestamp != nil {
+			terminating[workload]++
+		}
+	}
+
+	for _, workload := range slices.Sorted(maps.Keys(deleting)) {
+		// Rounded up, so a percentage lets small workloads lose a pod
+		limit, err := intstr.GetScaledValueFromIntOrPercent(&maxUnavailable, total[workload], true)
+		if err != nil {
+			return err
+		}
+		if terminating[workload]+deleting[workload] > limit {
+			return fmt.Errorf("refusing to delete %d pod(s) of %s: %d of its %d pods are already terminating and max_unavailable is %s (%d pods)",
+				deleting[workload], workload, terminating[workload], total[workload], maxUnavailable.String(), limit)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestCheckMaxUnavailable(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		pods           int
+		terminating    int
+		deleting       int
+		maxUnavailable string
+		wantErr        bool
+	}{
+		{name: "default", pods: 3, deleting: 1, maxUnavailable: "1"},
+		{name: "one already terminating", pods: 3, terminating: 1, deleting: 1, maxUnavailable: "1", wantErr: true},
+		{name: "more than the limit", pods: 3, deleting: 2, maxUnavailable: "1", wantErr: true},
+		// Percentages round up, so small workloads can still lose a pod
+		{name: "percentage of a small workload", pods: 3, deleting: 1, maxUnavailable: "25%"},
+		{name: "percentage of one pod", pods: 1, deleting: 1, maxUnavailable: "25%"},
+		{name: "percentage rounded up", pods: 5, deleting: 2, maxUnavailable: "25%"},
+		{name: "over the percentage", pods: 5, deleting: 3, maxUnavailable: "25%", wantErr: true},
+		{name: "zero", pods: 3, deleting: 1, maxUnavailable: "0%", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			controller := true
+			objects := make([]runtime.Object, 0, tt.pods)
+			pods := make([]corev1.Pod, 0, tt.pods)
+			for i := range tt.pods {
+				pod := corev1.Pod{ObjectMeta: v1.ObjectMeta{
+					Name:            fmt.Sprintf("db-%d", i),
+					Namespace:       "prod",
+					OwnerReferences: []v1.OwnerReference{{Kind: "StatefulSet", Name: "db", Controller: &controller}},
+				}}
+				if i < tt.terminating {
+					now := v1.Now()
+					pod.DeletionTimestamp = &now
+				}
+				objects = append(objects, &pod)
+				pods = append(pods, pod)
+			}
+			maxUnavailable, err := parseMaxUnavailable(tt.maxUnavailable)
+			if err != nil {
+				t.Fatalf("parseMaxUnavailable(%q): %v", tt.maxUnavailable, err)
+			}
+
+			deleting := pods[tt.terminating : tt.terminating+tt.deleting]
+			err = checkMaxUnavailable(context.Background(), fake.NewClientset(objects...), "prod", deleting, maxUnavailable)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkMaxUnavailable error = %v, want an error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseMaxUnavailable(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value   string
+		want    intstr.IntOrString
+		wantErr bool
+	}{
+		{value: "2", want: intstr.FromInt32(2)},
+		{value: "0", want: intstr.FromInt32(0)},
+		{value: "25%", want: intstr.FromString("25%")},
+		{value: "100%", want: intstr.FromString("100%")},
+		{value: "101%", wantErr: true},
+		{value: "-1", wantErr: true},
+		{value: "a%", wantErr: true},
+		{value: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseMaxUnavailable(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseMaxUnavailable(%q) error = %v, want an error: %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseMaxUnavailable(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/USA-RedDragon/metrics-actioner/internal/k8s"
+	"github.com/USA-RedDragon/metrics-actioner/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ErrDisruptionBudget is returned when an eviction would violate a PodDisruptionBudget
+var ErrDisruptionBudget = errors.New("eviction refused by a PodDisruptionBudget")
+
+// EvictPod evicts pods like `kubectl drain` does, through the Eviction
+// subresource so that PodDisruptionBudgets are respected. The pods are
+// targeted like DeletePod's and the grace_period option overrides their
+// termination grace period, in seconds. Evictions refused by a budget
+// fail with ErrDisruptionBudget once the other pods were evicted. A dry
+// run asks the API server whether the evictions would be allowed.
+type EvictPod struct {
+}
+
+type EvictPodOptions struct {
+	PodTarget
+	GracePeriod *int64
+}
+
+func (e *EvictPod) OptionSchema() Schema {
+	return Schema{
+		Options: []Option{
+			{Name: "namespace"},
+			{Name: "pod"},
+			{Name: "selector", Validate: validateSelector},
+			{Name: "grace_period", Validate: func(value string) error {
+				_, err := parseGracePeriod(value)
+				return err
+			}},
+		},
+		Check: checkPodTarget,
+	}
+}
+
+// Target returns the options with the pod and namespace defaulted to the alert's
+func (e *EvictPod) Target(req *Request) map[string]string {
+	return podTargetOptions(req)
+}
+
+func (e *EvictPod) Execute(ctx context.Context, req *Request) error {
+	slog.Info("EvictPod action executed")
+	var opts EvictPodOptions
+	// Get the options
+	for k, v := range req.Options {
+		switch k {
+		case "namespace":
+			opts.Namespace = v
+		case "pod":
+			opts.Pod = v
+		case "selector":
+			opts.Selector = v
+		case "grace_period":
+			if v == "" {
+				continue
+			}
+			gracePeriod, err := parseGracePeriod(v)
+			if err != nil {
+				return err
+			}
+			opts.GracePeriod = &gracePeriod
+		default:
+			slog.Warn("Unknown option", "option", k)
+		}
+	}
+	// Validate the options
+	if err := opts.resolve(req); err != nil {
+		return err
+	}
+
+	return e.evict(ctx, opts, req)
+}
+
+func (e *EvictPod) evict(ctx context.Context, opts EvictPodOptions, req *Request) error {
+	kubeconfig, err := k8s.GetConfig()
+	if err != nil {
+		return err
+	}
+
+	// Create the clientset
+	clientset, err := kubernetes.NewForConfig(kubeconfig)
+	if err != nil {
+		return err
+	}
+
+	ctx, span := tracing.Tracer("actions").Start(ctx, "k8s.EvictPods", trace.WithAttributes(
+		attribute.String("k8s.namespace.name", opts.Namespace),
+		attribute.String("k8s.pod.name", opts.Pod),
+		attribute.String("k8s.pod.selector", opts.Selector),
+	))
+	defer span.End()
+
+	pods, err := opts.find(ctx, clientset)
+	if err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+	span.SetAttributes(attribute.Int("k8s.pods", len(pods)))
+
+	var refused []error
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil {
+			fmt.Fprintf(req.Output, "pod %s/%s is already terminating\n", pod.Namespace, pod.Name)
+			continue
+		}
+
+		eviction := &policyv1.Eviction{
+			ObjectMeta: v1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace},
+			DeleteOptions: &v1.DeleteOptions{
+				GracePeriodSeconds: opts.GracePeriod,
+				// The UID precondition keeps a replacement pod with the same name from being evicted
+				Preconditions: v1.NewUIDPreconditions(string(pod.UID)),
+			},
+		}
+		if req.DryRun {
+			// The API server checks the budgets without evicting anything
+			eviction.DeleteOptions.DryRun = []string{v1.DryRunAll}
+			slog.Info("Dry run: would evict pod", "namespace", pod.Namespace, "name", pod.Name, "gracePeriodSeconds", opts.GracePeriod)
+		} else {
+			slog.Info("Evicting pod", "namespace", pod.Namespace, "name", pod.Name, "gracePeriodSeconds", opts.GracePeriod)
+		}
+
+		err := clientset.CoreV1().Pods(pod.Namespace).EvictV1(ctx, eviction)
+		switch {
+		case apierrors.IsNotFound(err):
+			fmt.Fprintf(req.Output, "pod %s/%s was already deleted\n", pod.Namespace, pod.Name)
+		case apierrors.IsTooManyRequests(err) && apierrors.HasStatusCause(err, policyv1.DisruptionBudgetCause):
+			// Try the other pods, the budget may only cover some of them
+			reason := disruptionBudgetReason(err)
+			slog.Warn("Eviction refused by a PodDisruptionBudget", "namespace", pod.Namespace, "name", pod.Name, "reason", reason)
+			fmt.Fprintf(req.Output, "eviction of pod %s/%s refused by a PodDisruptionBudget: %s\n", pod.Namespace, pod.Name, reason)
+			refused = append(refused, fmt.Errorf("pod %s/%s: %w: %s", pod.Namespace, pod.Name, ErrDisruptionBudget, reason))
+		case err != nil:
+			tracing.RecordError(span, err)
+			return err
+		case req.DryRun:
+			fmt.Fprintf(req.Output, "dry run: would evict pod %s/%s, the eviction is allowed\n", pod.Namespace, pod.Name)
+		default:
+			fmt.Fprintf(req.Output, "pod %s/%s evicted\n", pod.Namespace, pod.Name)
+		}
+	}
+
+	if err := errors.Join(refused...); err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+	return nil
+}
+
+// disruptionBudgetReason returns why a budget refused an eviction, like
+// `The disruption budget web needs 2 healthy pods and has 2 currently`
+func disruptionBudgetReason(err error) string {
+	var status apierrors.APIStatus
+	if errors.As(err, &status) {
+		if details := status.Status().Details; details != nil {
+			for _, cause := range details.Causes {
+				if cause.Type == policyv1.DisruptionBudgetCause && cause.Message != "" {
+					return cause.Message
+				}
+			}
+		}
+	}
+	return err.Error()
+}
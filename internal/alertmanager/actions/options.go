@@ -0,0 +1,113 @@
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Option describes an option accepted by an action
+type Option struct {
+	Name     string
+	Required bool
+	// Validate checks a value that doesn't use templates. Templated values
+	// are only known once rendered and are checked when the action runs.
+	Validate func(value string) error
+}
+
+// Schema describes the options accepted by an action
+type Schema struct {
+	Options []Option
+	// Check validates the options together, like options that exclude each
+	// other. It's only called once every option is valid on its own.
+	Check func(options map[string]string) error
+}
+
+// Validate checks the configured options against the schema, reporting
+// every problem prefixed with path
+func (s Schema) Validate(path string, options map[string]string) error {
+	var errs []error
+	known := make(map[string]bool, len(s.Options))
+	for _, option := range s.Options {
+		known[option.Name] = true
+		value, ok := options[option.Name]
+		if !ok || value == "" {
+			if option.Required {
+				errs = append(errs, fmt.Errorf("%s.%s: required option is missing", path, option.Name))
+			}
+			continue
+		}
+		if option.Validate != nil && !isTemplate(value) {
+			if err := option.Validate(value); err != nil {
+				errs = append(errs, fmt.Errorf("%s.%s: %w", path, option.Name, err))
+			}
+		}
+	}
+
+	unknown := make([]string, 0)
+	for name := range options {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		errs = append(errs, fmt.Errorf("%s.%s: unknown option, must be one of %s", path, name, strings.Join(s.names(), ", ")))
+	}
+	if len(errs) == 0 && s.Check != nil {
+		if err := s.Check(options); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s Schema) names() []string {
+	names := make([]string, 0, len(s.Options))
+	for _, option := range s.Options {
+		names = append(names, option.Name)
+	}
+	return names
+}
+
+// targetOptions returns the options with the unset ones in defaults taken
+// from the alert label they name, like the action does when it runs
+func targetOptions(req *Request, defaults map[string]string) map[string]string {
+	target := make(map[string]string, len(req.Options)+len(defaults))
+	maps.Copy(target, req.Options)
+	for option, label := range defaults {
+		if target[option] != "" {
+			continue
+		}
+		if value, ok := req.Labels[label]; ok {
+			target[option] = value
+		}
+	}
+	return target
+}
+
+// countSet returns how many of the named options are set
+func countSet(options map[string]string, names ...string) int {
+	count := 0
+	for _, name := range names {
+		if options[name] != "" {
+			count++
+		}
+	}
+	return count
+}
+
+func isTemplate(value string) bool {
+	return strings.Contains(value, "{{")
+}
+
+func parsePort(value string) (uint16, error) {
+	port, err := strconv.ParseUint(value, 10, 16)
+	if err != nil || port == 0 {
+		return 0, fmt.Errorf("invalid port %q: must be a number between 1 and 65535", value)
+	}
+	return uint16(port), nil
+}
@@ -0,0 +1,38 @@
+package actions
+
+import (
+	"testing"
+)
+
+func TestPodOptionSchemas(t *testing.T) {
+	t.Parallel()
+
+	schemas := map[string]Schema{
+		"delete-pod": (&DeletePod{}).OptionSchema(),
+		"evict-pod":  (&EvictPod{}).OptionSchema(),
+	}
+	tests := []struct {
+		name    string
+		options map[string]string
+		wantErr bool
+	}{
+		{name: "alert's pod", options: map[string]string{}},
+		{name: "pod", options: map[string]string{"pod": "web-1"}},
+		{name: "selector", options: map[string]string{"selector": "app=web"}},
+		{name: "pod and selector", options: map[string]string{"pod": "web-1", "selector": "app=web"}, wantErr: true},
+		{name: "templated pod and selector", options: map[string]string{"pod": "{{ .Labels.pod }}", "selector": "app=web"}, wantErr: true},
+		{name: "unknown option", options: map[string]string{"pods": "web-1"}, wantErr: true},
+	}
+	for action, schema := range schemas {
+		for _, tt := range tests {
+			t.Run(action+" "+tt.name, func(t *testing.T) {
+				t.Parallel()
+
+				err := schema.Validate("options", tt.options)
+				if (err != nil) != tt.wantErr {
+					t.Errorf("Validate(%v) error = %v, want an error: %v", tt.options, err, tt.wantErr)
+				}
+			})
+		}
+	}
+}
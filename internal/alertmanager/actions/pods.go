@@ -0,0 +1,141 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+// PodTarget selects the pods an action acts on
+type PodTarget struct {
+	Namespace string
+	// Pod is the name of a single pod
+	Pod string
+	// Selector is a label selector matching any number of pods
+	Selector string
+}
+
+func validateSelector(value string) error {
+	if _, err := labels.Parse(value); err != nil {
+		return fmt.Errorf("invalid label selector %q: %w", value, err)
+	}
+	return nil
+}
+
+// podTargetOptions returns the options with the namespace, and the pod
+// unless a selector is set, defaulted to the alert's
+func podTargetOptions(req *Request) map[string]string {
+	defaults := map[string]string{"namespace": "namespace"}
+	if req.Options["pod"] == "" && req.Options["selector"] == "" {
+		defaults["pod"] = "pod"
+	}
+	return targetOptions(req, defaults)
+}
+
+// checkPodTarget rejects options naming both a pod and a selector
+func checkPodTarget(options map[string]string) error {
+	if countSet(options, "pod", "selector") > 1 {
+		return fmt.Errorf("only one of the pod and selector options may be set")
+	}
+	return nil
+}
+
+// resolve checks the target, defaulting the pod and namespace to the alert's labels
+func (t *PodTarget) resolve(req *Request) error {
+	if t.Pod != "" && t.Selector != "" {
+		return fmt.Errorf("only one of the pod and selector options may be set")
+	}
+	if t.Pod == "" && t.Selector == "" {
+		// Default to the pod of the alert
+		var ok bool
+		t.Pod, ok = req.Labels["pod"]
+		if !ok || t.Pod == "" {
+			return fmt.Errorf("missing pod or selector option, and the alert has no pod label")
+		}
+	}
+	if t.Selector != "" {
+		if err := validateSelector(t.Selector); err != nil {
+			return err
+		}
+	}
+	if t.Namespace == "" {
+		// Default to the namespace of the alert
+		var ok bool
+		t.Namespace, ok = req.Labels["namespace"]
+		if !ok {
+			return fmt.Errorf("missing namespace option")
+		}
+	}
+	return nil
+}
+
+// find returns the targeted pods, failing if there are none
+func (t *PodTarget) find(ctx context.Context, clientset kubernetes.Interface) ([]corev1.Pod, error) {
+	pods := clientset.CoreV1().Pods(t.Namespace)
+	if t.Pod != "" {
+		pod, err := pods.Get(ctx, t.Pod, v1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return []corev1.Pod{*pod}, nil
+	}
+	list, err := pods.List(ctx, v1.ListOptions{LabelSelector: t.Selector})
+	if err != nil {
+		return nil, err
+	}
+	if len(list.Items) == 0 {
+		return nil, fmt.Errorf("no pods in namespace %s match selector %q", t.Namespace, t.Selector)
+	}
+	return list.Items, nil
+}
+
+// workloadResolver finds the workload a pod belongs to, following
+// ReplicaSets up to the Deployment that owns them
+type workloadResolver struct {
+	clientset kubernetes.Interface
+	namespace string
+	// replicaSets caches the owners of the ReplicaSets seen so far
+	replicaSets map[string]string
+}
+
+func newWorkloadResolver(clientset kubernetes.Interface, namespace string) *workloadResolver {
+	return &workloadResolver{
+		clientset:   clientset,
+		namespace:   namespace,
+		replicaSets: make(map[string]string),
+	}
+}
+
+// workload returns the workload of a pod like `deployment/name`, or an
+// empty string if the pod isn't managed by a controller
+func (w *workloadResolver) workload(ctx context.Context, pod *corev1.Pod) (string, error) {
+	owner := v1.GetControllerOf(pod)
+	if owner == nil {
+		return "", nil
+	}
+	if owner.Kind != "ReplicaSet" {
+		return workloadName(owner), nil
+	}
+
+	if workload, ok := w.replicaSets[owner.Name]; ok {
+		return workload, nil
+	}
+	replicaSet, err := w.clientset.AppsV1().ReplicaSets(w.namespace).Get(ctx, owner.Name, v1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	workload := workloadName(owner)
+	if deployment := v1.GetControllerOf(replicaSet); deployment != nil {
+		workload = workloadName(deployment)
+	}
+	w.replicaSets[owner.Name] = workload
+	return workload, nil
+}
+
+func workloadName(owner *v1.OwnerReference) string {
+	return fmt.Sprintf("%s/%s", owner.Kind, owner.Name)
+}
@@ -0,0 +1,27 @@
+package actions
+
+import (
+	"io"
+
+	"github.com/USA-RedDragon/metrics-actioner/internal/alertmanager/models"
+)
+
+// Request is the input of an action execution
+type Request struct {
+	// Webhook is the notification that triggered the action
+	Webhook *models.Webhook
+	// Labels are the labels of the matched alert,
+	// or the webhook's common labels when matching the whole group
+	Labels models.Labels
+	// Options are the rule's rendered options
+	Options map[string]string
+	// DryRun actions log what they would do without doing it
+	DryRun bool
+	// State is where reversible actions save the state they changed for a
+	// later Revert, and where Revert reads it back from. It's kept across
+	// the retries of an execution, so a retry can tell what an earlier
+	// attempt already changed. Never nil.
+	State map[string]string
+	// Output captures what the action did for the execution history, never nil
+	Output io.Writer
+}
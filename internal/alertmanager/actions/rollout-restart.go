@@ -0,0 +1,129 @@
+package actions
+
+//nolint:golint,revive
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/USA-RedDragon/metrics-actioner/internal/k8s"
+	"github.com/USA-RedDragon/metrics-actioner/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	_ "k8s.io/client-go/plugin/pkg/client/auth"
+)
+
+// WorkloadKind is a kind of workload with a pod template
+type WorkloadKind string
+
+const (
+	WorkloadKindDeployment  WorkloadKind = "deployment"
+	WorkloadKindStatefulSet WorkloadKind = "statefulset"
+	WorkloadKindDaemonSet   WorkloadKind = "daemonset"
+)
+
+// RolloutRestart restarts the pods of a workload like
+// `kubectl rollout restart`. The workload is named by the option of
+// the same name as its kind, e.g. `deployment`.
+type RolloutRestart struct {
+	Kind WorkloadKind
+}
+
+type RolloutRestartOptions struct {
+	Namespace string
+	Name      string
+}
+
+func (r *RolloutRestart) OptionSchema() Schema {
+	return Schema{Options: []Option{
+		{Name: "namespace"},
+		{Name: string(r.Kind), Required: true},
+	}}
+}
+
+// Target returns the options with the namespace defaulted to the alert's
+func (r *RolloutRestart) Target(req *Request) map[string]string {
+	return targetOptions(req, map[string]string{"namespace": "namespace"})
+}
+
+func (r *RolloutRestart) Execute(ctx context.Context, req *Request) error {
+	slog.Info("RolloutRestart action executed", "kind", r.Kind)
+	var opts RolloutRestartOptions
+	// Get the options
+	for k, v := range req.Options {
+		switch k {
+		case "namespace":
+			opts.Namespace = v
+		case string(r.Kind):
+			opts.Name = v
+		default:
+			slog.Warn("Unknown option", "option", k)
+		}
+	}
+	// Validate the options
+	if opts.Name == "" {
+		return fmt.Errorf("missing %s option", r.Kind)
+	}
+	if opts.Namespace == "" {
+		// Default to the namespace of the alert
+		var ok bool
+		opts.Namespace, ok = req.Labels["namespace"]
+		if !ok {
+			return fmt.Errorf("missing namespace option")
+		}
+	}
+
+	return r.restart(ctx, opts, req)
+}
+
+func (r *RolloutRestart) restart(ctx context.Context, opts RolloutRestartOptions, req *Request) error {
+	// Now we essentially run `kubectl -n <namespace> rollout restart <kind> <name>`
+	data := fmt.Sprintf(`{"spec": {"template": {"metadata": {"annotations": {"kubectl.kubernetes.io/restartedAt": "%s"}}}}}`, time.Now().Format("20060102150405"))
+	if req.DryRun {
+		slog.Info("Dry run: would patch workload", "kind", r.Kind, "namespace", opts.Namespace, "name", opts.Name, "patchType", types.StrategicMergePatchType, "patch", data)
+		fmt.Fprintf(req.Output, "dry run: would patch %s %s/%s with %s patch %s\n", r.Kind, opts.Namespace, opts.Name, types.StrategicMergePatchType, data)
+		return nil
+	}
+
+	slog.Info("Restarting workload", "kind", r.Kind, "namespace", opts.Namespace, "name", opts.Name)
+
+	kubeconfig, err := k8s.GetConfig()
+	if err != nil {
+		return err
+	}
+
+	// Create the clientset
+	clientset, err := kubernetes.NewForConfig(kubeconfig)
+	if err != nil {
+		return err
+	}
+
+	ctx, span := tracing.Tracer("actions").Start(ctx, "k8s.Patch", trace.WithAttributes(
+		attribute.String("k8s.namespace.name", opts.Namespace),
+		attribute.String("k8s."+string(r.Kind)+".name", opts.Name),
+	))
+	defer span.End()
+
+	patchOptions := v1.PatchOptions{}
+	switch r.Kind {
+	case WorkloadKindDeployment:
+		_, err = clientset.AppsV1().Deployments(opts.Namespace).Patch(ctx, opts.Name, types.StrategicMergePatchType, []byte(data), patchOptions)
+	case WorkloadKindStatefulSet:
+		_, err = clientset.AppsV1().StatefulSets(opts.Namespace).Patch(ctx, opts.Name, types.StrategicMergePatchType, []byte(data), patchOptions)
+	case WorkloadKindDaemonSet:
+		_, err = clientset.AppsV1().DaemonSets(opts.Namespace).Patch(ctx, opts.Name, types.StrategicMergePatchType, []byte(data), patchOptions)
+	default:
+		err = fmt.Errorf("unsupported workload kind: %s", r.Kind)
+	}
+	if err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+	fmt.Fprintf(req.Output, "%s %s/%s restarted\n", r.Kind, opts.Namespace, opts.Name)
+
+	return nil
+}
@@ -0,0 +1,358 @@
+package actions
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/USA-RedDragon/metrics-actioner/internal/k8s"
+	"github.com/USA-RedDragon/metrics-actioner/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Scale changes the replicas of a Deployment or StatefulSet through the
+// scale subresource. The workload is named by the deployment or
+// statefulset option. Replicas are either absolute, e.g. `3`, relative,
+// e.g. `+2` or `-1`, or a factor, e.g. `x2`, and are kept within the
+// optional min and max bounds. Reverting restores the replicas the
+// workload had before it was scaled.
+type Scale struct {
+}
+
+type ScaleOptions struct {
+	Namespace string
+	Kind      WorkloadKind
+	Name      string
+	Replicas  ReplicaChange
+	Min       *int32
+	Max       *int32
+	// from is the replicas an earlier attempt scaled from, if any
+	from *int32
+}
+
+// ReplicaChange is a change to a workload's replicas
+type ReplicaChange struct {
+	// Op is = for absolute replicas, + or - for a delta and x for a factor
+	Op     byte
+	Value  int32
+	Factor float64
+}
+
+// ParseReplicaChange parses replicas like `3`, `+2`, `-1` or `x1.5`
+func ParseReplicaChange(s string) (ReplicaChange, error) {
+	s = strings.TrimSpace(s)
+	invalid := fmt.Errorf("invalid replicas %q: must be a number like 3, a delta like +2 or -1, or a factor like x2", s)
+	if s == "" {
+		return ReplicaChange{}, invalid
+	}
+	// The value after an operator must start with a digit, which ParseInt and ParseFloat don't require
+	if !isDigit(s[0]) && (len(s) < 2 || !isDigit(s[1])) {
+		return ReplicaChange{}, invalid
+	}
+	switch s[0] {
+	case 'x', 'X':
+		factor, err := strconv.ParseFloat(s[1:], 64)
+		if err != nil || factor < 0 || math.IsInf(factor, 0) || math.IsNaN(factor) {
+			return ReplicaChange{}, invalid
+		}
+		return ReplicaChange{Op: 'x', Factor: factor}, nil
+	case '+', '-':
+		value, err := strconv.ParseInt(s[1:], 10, 32)
+		if err != nil || value < 0 {
+			return ReplicaChange{}, invalid
+		}
+		return ReplicaChange{Op: s[0], Value: int32(value)}, nil
+	default:
+		value, err := strconv.ParseInt(s, 10, 32)
+		if err != nil || value < 0 {
+			return ReplicaChange{}, invalid
+		}
+		return ReplicaChange{Op: '=', Value: int32(value)}, nil
+	}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// Apply returns the replicas after the change, rounding factors up
+func (c ReplicaChange) Apply(current int32) int32 {
+	var replicas int64
+	switch c.Op {
+	case '+':
+		replicas = int64(current) + int64(c.Value)
+	case '-':
+		replicas = int64(current) - int64(c.Value)
+	case 'x':
+		replicas = int64(math.Ceil(float64(current) * c.Factor))
+	default:
+		replicas = int64(c.Value)
+	}
+	return int32(max(0, min(replicas, math.MaxInt32)))
+}
+
+func parseReplicaBound(s string) (int32, error) {
+	value, err := strconv.ParseInt(s, 10, 32)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid replica bound %q: must be a non-negative number", s)
+	}
+	return int32(value), nil
+}
+
+func (s *Scale) OptionSchema() Schema {
+	validateBound := func(value string) error {
+		_, err := parseReplicaBound(value)
+		return err
+	}
+	return Schema{
+		Options: []Option{
+			{Name: "namespace"},
+			{Name: string(WorkloadKindDeployment)},
+			{Name: string(WorkloadKindStatefulSet)},
+			{Name: "replicas", Required: true, Validate: func(value string) error {
+				_, err := ParseReplicaChange(value)
+				return err
+			}},
+			{Name: "min", Validate: validateBound},
+			{Name: "max", Validate: validateBound},
+		},
+		Check: checkScaleOptions,
+	}
+}
+
+// checkScaleOptions requires exactly one workload and bounds that don't
+// exclude each other
+func checkScaleOptions(options map[string]string) error {
+	if countSet(options, string(WorkloadKindDeployment), string(WorkloadKindStatefulSet)) != 1 {
+		return fmt.Errorf("exactly one of the deployment and statefulset options must be set")
+	}
+	minimum, maximum := options["min"], options["max"]
+	if minimum == "" || maximum == "" || isTemplate(minimum) || isTemplate(maximum) {
+		return nil
+	}
+	// The bounds were already validated on their own
+	minReplicas, _ := parseReplicaBound(minimum)
+	maxReplicas, _ := parseReplicaBound(maximum)
+	if minReplicas > maxReplicas {
+		return fmt.Errorf("min replicas %d is greater than max replicas %d", minReplicas, maxReplicas)
+	}
+	return nil
+}
+
+// Target returns the options with the namespace defaulted to the alert's
+func (s *Scale) Target(req *Request) map[string]string {
+	return targetOptions(req, map[string]string{"namespace": "namespace"})
+}
+
+func (s *Scale) Execute(ctx context.Context, req *Request) error {
+	slog.Info("Scale action executed")
+	var opts ScaleOptions
+	// Get the options
+	for k, v := range req.Options {
+		switch k {
+		case "namespace":
+			opts.Namespace = v
+		case string(WorkloadKindDeployment), string(WorkloadKindStatefulSet):
+			if v == "" {
+				continue
+			}
+			if opts.Name != "" {
+				return fmt.Errorf("only one of the deployment and statefulset options may be set")
+			}
+			opts.Kind = WorkloadKind(k)
+			opts.Name = v
+		case "replicas":
+			replicas, err := ParseReplicaChange(v)
+			if err != nil {
+				return err
+			}
+			opts.Replicas = replicas
+		case "min", "max":
+			if v == "" {
+				continue
+			}
+			bound, err := parseReplicaBound(v)
+			if err != nil {
+				return err
+			}
+			if k == "min" {
+				opts.Min = &bound
+			} else {
+				opts.Max = &bound
+			}
+		default:
+			slog.Warn("Unknown option", "option", k)
+		}
+	}
+	// Validate the options
+	if opts.Name == "" {
+		return fmt.Errorf("missing deployment or statefulset option")
+	}
+	if opts.Replicas.Op == 0 {
+		return fmt.Errorf("missing replicas option")
+	}
+	if opts.Min != nil && opts.Max != nil && *opts.Min > *opts.Max {
+		return fmt.Errorf("min replicas %d is greater than max replicas %d", *opts.Min, *opts.Max)
+	}
+	if opts.Namespace == "" {
+		// Default to the namespace of the alert
+		var ok bool
+		opts.Namespace, ok = req.Labels["namespace"]
+		if !ok {
+			return fmt.Errorf("missing namespace option")
+		}
+	}
+
+	if err := opts.resume(req.State); err != nil {
+		return err
+	}
+	return s.scale(ctx, opts, req, func(from int32) {
+		// Save the previous replicas for a revert
+		req.State["kind"] = string(opts.Kind)
+		req.State["namespace"] = opts.Namespace
+		req.State["name"] = opts.Name
+		req.State["replicas"] = strconv.FormatInt(int64(from), 10)
+	})
+}
+
+// Revert scales the workload back to the replicas saved by Execute, ignoring the min and max bounds
+func (s *Scale) Revert(ctx context.Context, req *Request) error {
+	slog.Info("Scale action reverted")
+	opts := ScaleOptions{
+		Kind:      WorkloadKind(req.State["kind"]),
+		Namespace: req.State["namespace"],
+		Name:      req.State["name"],
+	}
+	if opts.Name == "" || opts.Namespace == "" {
+		return fmt.Errorf("missing the workload to revert")
+	}
+	replicas, err := parseReplicaBound(req.State["replicas"])
+	if err != nil {
+		return err
+	}
+	opts.Replicas = ReplicaChange{Op: '=', Value: replicas}
+
+	return s.scale(ctx, opts, req, nil)
+}
+
+// resume applies the change to the replicas saved by an earlier attempt, if
+// any. That attempt may have scaled the workload before failing, and the
+// change mustn't be applied twice.
+func (o *ScaleOptions) resume(state map[string]string) error {
+	saved := state["replicas"]
+	if saved == "" {
+		return nil
+	}
+	from, err := parseReplicaBound(saved)
+	if err != nil {
+		return err
+	}
+	o.from = &from
+	return nil
+}
+
+// desiredReplicas returns the replicas after the change, within the min and max bounds
+func (o *ScaleOptions) desiredReplicas(current int32) int32 {
+	replicas := o.Replicas.Apply(current)
+	if o.Min != nil {
+		replicas = max(replicas, *o.Min)
+	}
+	if o.Max != nil {
+		replicas = min(replicas, *o.Max)
+	}
+	return replicas
+}
+
+// scaleClient is the scale subresource of Deployments and StatefulSets
+type scaleClient interface {
+	GetScale(ctx context.Context, name string, options v1.GetOptions) (*autoscalingv1.Scale, error)
+	UpdateScale(ctx context.Context, name string, scale *autoscalingv1.Scale, opts v1.UpdateOptions) (*autoscalingv1.Scale, error)
+}
+
+func newScaleClient(kind WorkloadKind, namespace string) (scaleClient, error) {
+	kubeconfig, err := k8s.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the clientset
+	clientset, err := kubernetes.NewForConfig(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case WorkloadKindDeployment:
+		return clientset.AppsV1().Deployments(namespace), nil
+	case WorkloadKindStatefulSet:
+		return clientset.AppsV1().StatefulSets(namespace), nil
+	default:
+		return nil, fmt.Errorf("unsupported workload kind: %s", kind)
+	}
+}
+
+// scale applies the replica change. Unless it's a dry run, save is called
+// with the replicas the change applies to before the workload is updated,
+// as the update may go through even if it returns an error.
+func (s *Scale) scale(ctx context.Context, opts ScaleOptions, req *Request, save func(from int32)) error {
+	client, err := newScaleClient(opts.Kind, opts.Namespace)
+	if err != nil {
+		return err
+	}
+	return scaleWorkload(ctx, client, opts, req, save)
+}
+
+func scaleWorkload(ctx context.Context, client scaleClient, opts ScaleOptions, req *Request, save func(from int32)) error {
+	ctx, span := tracing.Tracer("actions").Start(ctx, "k8s.Scale", trace.WithAttributes(
+		attribute.String("k8s.namespace.name", opts.Namespace),
+		attribute.String("k8s."+string(opts.Kind)+".name", opts.Name),
+	))
+	defer span.End()
+
+	current, err := client.GetScale(ctx, opts.Name, v1.GetOptions{})
+	if err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+	previous := current.Spec.Replicas
+	from := previous
+	if opts.from != nil {
+		from = *opts.from
+	}
+
+	replicas := opts.desiredReplicas(from)
+	span.SetAttributes(attribute.Int("k8s.replicas.previous", int(previous)), attribute.Int("k8s.replicas.desired", int(replicas)))
+	if save != nil && !req.DryRun {
+		save(from)
+	}
+
+	if replicas == previous {
+		slog.Info("Workload already has the desired replicas", "kind", opts.Kind, "namespace", opts.Namespace, "name", opts.Name, "replicas", replicas)
+		fmt.Fprintf(req.Output, "%s %s/%s already has %d replicas\n", opts.Kind, opts.Namespace, opts.Name, replicas)
+		return nil
+	}
+
+	if req.DryRun {
+		slog.Info("Dry run: would scale workload", "kind", opts.Kind, "namespace", opts.Namespace, "name", opts.Name, "previousReplicas", previous, "replicas", replicas)
+		fmt.Fprintf(req.Output, "dry run: would scale %s %s/%s from %d to %d replicas\n", opts.Kind, opts.Namespace, opts.Name, previous, replicas)
+		return nil
+	}
+
+	slog.Info("Scaling workload", "kind", opts.Kind, "namespace", opts.Namespace, "name", opts.Name, "previousReplicas", previous, "replicas", replicas)
+	current.Spec.Replicas = replicas
+	// The scale's resource version makes this fail if the replicas changed since they were read
+	if _, err := client.UpdateScale(ctx, opts.Name, current, v1.UpdateOptions{}); err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+	fmt.Fprintf(req.Output, "%s %s/%s scaled from %d to %d replicas\n", opts.Kind, opts.Namespace, opts.Name, previous, replicas)
+
+	return nil
+}
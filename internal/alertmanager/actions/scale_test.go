@@ -0,0 +1,261 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"strconv"
+	"testing"
+
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseReplicaChange(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		replicas string
+		want     ReplicaChange
+		wantErr  bool
+	}{
+		{replicas: "3", want: ReplicaChange{Op: '=', Value: 3}},
+		{replicas: "0", want: ReplicaChange{Op: '=', Value: 0}},
+		{replicas: " 3 ", want: ReplicaChange{Op: '=', Value: 3}},
+		{replicas: "+2", want: ReplicaChange{Op: '+', Value: 2}},
+		{replicas: "-1", want: ReplicaChange{Op: '-', Value: 1}},
+		{replicas: "x2", want: ReplicaChange{Op: 'x', Factor: 2}},
+		{replicas: "X1.5", want: ReplicaChange{Op: 'x', Factor: 1.5}},
+		{replicas: "x0.5", want: ReplicaChange{Op: 'x', Factor: 0.5}},
+		{replicas: "", wantErr: true},
+		{replicas: "+", wantErr: true},
+		{replicas: "x", wantErr: true},
+		{replicas: "+-1", wantErr: true},
+		{replicas: "--1", wantErr: true},
+		{replicas: "x-2", wantErr: true},
+		{replicas: "x+2", wantErr: true},
+		{replicas: "xInf", wantErr: true},
+		{replicas: "xNaN", wantErr: true},
+		{replicas: "1.5", wantErr: true},
+		{replicas: "three", wantErr: true},
+		{replicas: "2147483648", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.replicas, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseReplicaChange(tt.replicas)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseReplicaChange(%q) = %+v, want an error", tt.replicas, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseReplicaChange(%q): %v", tt.replicas, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseReplicaChange(%q) = %+v, want %+v", tt.replicas, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReplicaChangeApply(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		replicas string
+		current  int32
+		want     int32
+	}{
+		{replicas: "5", current: 2, want: 5},
+		{replicas: "0", current: 2, want: 0},
+		{replicas: "+2", current: 3, want: 5},
+		{replicas: "-1", current: 3, want: 2},
+		// Replicas can't go negative
+		{replicas: "-5", current: 3, want: 0},
+		{replicas: "x2", current: 3, want: 6},
+		// Factors round up
+		{replicas: "x1.5", current: 3, want: 5},
+		{replicas: "x0.5", current: 3, want: 2},
+		{replicas: "x2", current: 0, want: 0},
+		// Replicas can't overflow
+		{replicas: "+10", current: math.MaxInt32 - 1, want: math.MaxInt32},
+		{replicas: "x3", current: math.MaxInt32 / 2, want: math.MaxInt32},
+	}
+	for _, tt := range tests {
+		t.Run(tt.replicas, func(t *testing.T) {
+			t.Parallel()
+
+			change, err := ParseReplicaChange(tt.replicas)
+			if err != nil {
+				t.Fatalf("ParseReplicaChange(%q): %v", tt.replicas, err)
+			}
+			if got := change.Apply(tt.current); got != tt.want {
+				t.Errorf("%s applied to %d = %d, want %d", tt.replicas, tt.current, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseReplicaBound(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		bound   string
+		want    int32
+		wantErr bool
+	}{
+		{bound: "0", want: 0},
+		{bound: "20", want: 20},
+		{bound: "-1", wantErr: true},
+		{bound: "+1", want: 1},
+		{bound: "x2", wantErr: true},
+		{bound: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.bound, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := parseReplicaBound(tt.bound)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseReplicaBound(%q) error = %v, want an error: %v", tt.bound, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseReplicaBound(%q) = %d, want %d", tt.bound, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScaleOptionsDesiredReplicas(t *testing.T) {
+	t.Parallel()
+
+	bound := func(value int32) *int32 {
+		return &value
+	}
+	tests := []struct {
+		name     string
+		replicas string
+		min, max *int32
+		current  int32
+		want     int32
+	}{
+		{name: "unbounded", replicas: "x2", current: 3, want: 6},
+		{name: "capped by max", replicas: "x2", max: bound(5), current: 3, want: 5},
+		{name: "raised to min", replicas: "-2", min: bound(2), current: 3, want: 2},
+		{name: "within bounds", replicas: "+1", min: bound(2), max: bound(5), current: 3, want: 4},
+		// Bounds also apply to workloads already outside them
+		{name: "already above max", replicas: "+0", max: bound(5), current: 8, want: 5},
+		{name: "absolute below min", replicas: "0", min: bound(1), current: 3, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			change, err := ParseReplicaChange(tt.replicas)
+			if err != nil {
+				t.Fatalf("ParseReplicaChange(%q): %v", tt.replicas, err)
+			}
+			opts := ScaleOptions{Replicas: change, Min: tt.min, Max: tt.max}
+			if got := opts.desiredReplicas(tt.current); got != tt.want {
+				t.Errorf("desiredReplicas(%d) = %d, want %d", tt.current, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScaleOptionSchema(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		options map[string]string
+		wantErr bool
+	}{
+		{name: "deployment", options: map[string]string{"deployment": "web", "replicas": "x2"}},
+		{name: "statefulset", options: map[string]string{"statefulset": "db", "replicas": "+1"}},
+		{name: "templated workload", options: map[string]string{"deployment": "{{ .Labels.deployment }}", "replicas": "x2"}},
+		{name: "no workload", options: map[string]string{"replicas": "x2"}, wantErr: true},
+		{name: "both workloads", options: map[string]string{"deployment": "web", "statefulset": "db", "replicas": "x2"}, wantErr: true},
+		{name: "bounds", options: map[string]string{"deployment": "web", "replicas": "x2", "min": "2", "max": "5"}},
+		{name: "equal bounds", options: map[string]string{"deployment": "web", "replicas": "x2", "min": "3", "max": "3"}},
+		{name: "min above max", options: map[string]string{"deployment": "web", "replicas": "x2", "min": "5", "max": "2"}, wantErr: true},
+		// Templated bounds are checked when the action runs
+		{name: "templated bound", options: map[string]string{"deployment": "web", "replicas": "x2", "min": "{{ .Labels.min }}", "max": "2"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := (&Scale{}).OptionSchema().Validate("options", tt.options)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate(%v) error = %v, want an error: %v", tt.options, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// fakeScaleClient applies updates even when it returns an error, like an
+// update whose response timed out
+type fakeScaleClient struct {
+	replicas  int32
+	updateErr error
+}
+
+func (c *fakeScaleClient) GetScale(context.Context, string, v1.GetOptions) (*autoscalingv1.Scale, error) {
+	return &autoscalingv1.Scale{Spec: autoscalingv1.ScaleSpec{Replicas: c.replicas}}, nil
+}
+
+func (c *fakeScaleClient) UpdateScale(_ context.Context, _ string, scale *autoscalingv1.Scale, _ v1.UpdateOptions) (*autoscalingv1.Scale, error) {
+	c.replicas = scale.Spec.Replicas
+	return scale, c.updateErr
+}
+
+func TestScaleWorkloadRetry(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		replicas string
+		want     int32
+	}{
+		{replicas: "+2", want: 5},
+		{replicas: "x2", want: 6},
+		{replicas: "4", want: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.replicas, func(t *testing.T) {
+			t.Parallel()
+
+			change, err := ParseReplicaChange(tt.replicas)
+			if err != nil {
+				t.Fatalf("ParseReplicaChange(%q): %v", tt.replicas, err)
+			}
+			client := &fakeScaleClient{replicas: 3, updateErr: context.DeadlineExceeded}
+			req := &Request{State: map[string]string{}, Output: io.Discard}
+			save := func(from int32) {
+				req.State["replicas"] = strconv.FormatInt(int64(from), 10)
+			}
+			// The first attempt scales the workload but fails, so it's retried
+			for attempt := 1; attempt <= 2; attempt++ {
+				opts := ScaleOptions{Kind: WorkloadKindDeployment, Namespace: "prod", Name: "web", Replicas: change}
+				if err := opts.resume(req.State); err != nil {
+					t.Fatalf("attempt %d: resume: %v", attempt, err)
+				}
+				err := scaleWorkload(context.Background(), client, opts, req, save)
+				if attempt == 1 && !errors.Is(err, context.DeadlineExceeded) {
+					t.Fatalf("attempt 1 error = %v, want %v", err, context.DeadlineExceeded)
+				}
+				client.updateErr = nil
+			}
+			if client.replicas != tt.want {
+				t.Errorf("scaled to %d replicas, want %d", client.replicas, tt.want)
+			}
+			if got := req.State["replicas"]; got != "3" {
+				t.Errorf("saved %s previous replicas, want 3", got)
+			}
+		})
+	}
+}
@@ -1,14 +1,18 @@
 package actions
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"log/slog"
 	"net"
 	"os"
 	"strconv"
 	"strings"
 
-	"github.com/USA-RedDragon/metrics-actioner/internal/alertmanager/models"
+	"github.com/USA-RedDragon/metrics-actioner/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/crypto/ssh"
 )
 
@@ -18,6 +22,8 @@ const (
 	SSHOptionHostKeyIgnore SSHOptionHostKey = "ignore"
 )
 
+const defaultSSHPort = 22
+
 type SSH struct {
 }
 
@@ -30,12 +36,31 @@ type SSHOptions struct {
 	HostKeys SSHOptionHostKey
 }
 
-func (s *SSH) Execute(webhook *models.Webhook, options map[string]string) error {
+func (s *SSH) OptionSchema() Schema {
+	return Schema{Options: []Option{
+		{Name: "command", Required: true},
+		{Name: "host", Required: true},
+		{Name: "port", Validate: func(value string) error {
+			_, err := parsePort(value)
+			return err
+		}},
+		{Name: "user", Required: true},
+		{Name: "key", Required: true},
+		{Name: "hostKeys", Validate: func(value string) error {
+			if SSHOptionHostKey(value) == SSHOptionHostKeyIgnore {
+				return nil
+			}
+			return newHostKeyDB().Read(strings.NewReader(value), "hostKeys")
+		}},
+	}}
+}
+
+func (s *SSH) Execute(ctx context.Context, req *Request) error {
 	slog.Info("SSH action executed")
-	var opts SSHOptions
+	opts := SSHOptions{Port: defaultSSHPort}
 
 	// Get the options
-	for k, v := range options {
+	for k, v := range req.Options {
 		switch k {
 		case "command":
 			opts.Command = v
@@ -43,13 +68,13 @@ func (s *SSH) Execute(webhook *models.Webhook, options map[string]string) error
 			opts.Host = v
 		case "port":
 			if v == "" {
-				v = "22"
+				continue
 			}
-			intPort, err := strconv.Atoi(v)
+			port, err := parsePort(v)
 			if err != nil {
-				return fmt.Errorf("invalid port option: %s", v)
+				return fmt.Errorf("invalid port option: %w", err)
 			}
-			opts.Port = uint16(intPort)
+			opts.Port = port
 		case "user":
 			opts.User = v
 		case "key":
@@ -83,10 +108,16 @@ func (s *SSH) Execute(webhook *models.Webhook, options map[string]string) error
 		return fmt.Errorf("error parsing key: %w", err)
 	}
 
-	return s.runCommand(opts, signer)
+	if req.DryRun {
+		slog.Info("Dry run: would run command", "command", opts.Command, "host", opts.Host, "port", opts.Port, "user", opts.User)
+		fmt.Fprintf(req.Output, "dry run: would run %q on %s@%s:%d\n", opts.Command, opts.User, opts.Host, opts.Port)
+		return nil
+	}
+
+	return s.runCommand(ctx, opts, signer, req.Output)
 }
 
-func (s *SSH) runCommand(opts SSHOptions, key ssh.Signer) error {
+func (s *SSH) runCommand(ctx context.Context, opts SSHOptions, key ssh.Signer, output io.Writer) error {
 	slog.Info("Running command", "command", opts.Command, "host", opts.Host, "port", opts.Port, "user", opts.User)
 
 	var hostkeyCallback ssh.HostKeyCallback
@@ -119,24 +150,67 @@ func (s *SSH) runCommand(opts SSHOptions, key ssh.Signer) error {
 		},
 	}
 
-	conn, err := ssh.Dial("tcp", fmt.Sprintf("%s:%d", opts.Host, opts.Port), conf)
+	addr := net.JoinHostPort(opts.Host, strconv.Itoa(int(opts.Port)))
+	attrs := trace.WithAttributes(
+		attribute.String("server.address", opts.Host),
+		attribute.Int("server.port", int(opts.Port)),
+		attribute.String("ssh.user", opts.User),
+	)
+
+	dialCtx, dialSpan := tracing.Tracer("actions").Start(ctx, "ssh.Dial", attrs)
+	dialer := net.Dialer{}
+	netConn, err := dialer.DialContext(dialCtx, "tcp", addr)
+	if err != nil {
+		tracing.RecordError(dialSpan, err)
+		dialSpan.End()
+		return fmt.Errorf("error dialing: %w", err)
+	}
+	defer netConn.Close()
+
+	// Closing the connection aborts the handshake or the running command
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			netConn.Close()
+		case <-done:
+		}
+	}()
+
+	sshConn, chans, reqs, err := ssh.NewClientConn(netConn, addr, conf)
 	if err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		tracing.RecordError(dialSpan, err)
+		dialSpan.End()
 		return fmt.Errorf("error dialing: %w", err)
 	}
+	dialSpan.End()
+	conn := ssh.NewClient(sshConn, chans, reqs)
 	defer conn.Close()
 
+	_, sessionSpan := tracing.Tracer("actions").Start(ctx, "ssh.Session", attrs)
+	defer sessionSpan.End()
+
 	session, err := conn.NewSession()
 	if err != nil {
+		tracing.RecordError(sessionSpan, err)
 		return fmt.Errorf("error creating session: %w", err)
 	}
 	defer session.Close()
 
-	// Run the command, redirecting the output to the logger
-	session.Stdout = &stdToSlogInfoWriter{}
-	session.Stderr = &stdToSlogErrWriter{}
+	// Run the command, redirecting the output to the logger and capturing it
+	session.Stdout = io.MultiWriter(&stdToSlogInfoWriter{}, output)
+	session.Stderr = io.MultiWriter(&stdToSlogErrWriter{}, output)
 
 	err = session.Run(opts.Command)
 	if err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		tracing.RecordError(sessionSpan, err)
 		return fmt.Errorf("error running command: %w", err)
 	}
 
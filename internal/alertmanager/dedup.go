@@ -0,0 +1,100 @@
+package alertmanager
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/USA-RedDragon/metrics-actioner/internal/alertmanager/models"
+)
+
+const suppressedDuplicate = "duplicate"
+
+// ErrDuplicate is returned when the alert occurrence was already acted on
+var ErrDuplicate = errors.New("alert occurrence was already acted on")
+
+// deduplicator remembers which alert occurrences each rule acted on, so
+// that AlertManager retries, HA peers and repeat intervals don't trigger
+// an action twice for the same occurrence
+type deduplicator struct {
+	mu   sync.Mutex
+	ttl  time.Duration
+	seen map[string]time.Time
+}
+
+func newDeduplicator(ttl time.Duration) *deduplicator {
+	return &deduplicator{
+		ttl:  ttl,
+		seen: make(map[string]time.Time),
+	}
+}
+
+// occurrenceKey identifies an alert occurrence, which AlertManager
+// keeps the fingerprint and start time of until it resolves
+func occurrenceKey(ruleKey, groupKey string, alert *models.Alert) string {
+	return ruleKey + "\x00" + groupKey + "\x00" + string(alert.Status) + "\x00" + alert.Fingerprint + "\x00" + alert.StartsAt.UTC().Format(time.RFC3339Nano)
+}
+
+// occurrenceKeys returns the keys of the alert occurrences the action would act on.
+// Alerts without a fingerprint can't be deduplicated and are left out.
+func occurrenceKeys(alertRule *rule, data *TemplateData) []string {
+	// Alerts are deduplicated across groups in the alert match mode
+	groupKey := data.Webhook.GroupKey
+	if data.Alert != nil {
+		groupKey = ""
+	}
+
+	var keys []string
+	for _, alert := range matchedAlerts(data) {
+		if alert.Fingerprint == "" {
+			continue
+		}
+		// Dry runs don't count as acting on the occurrence
+		keys = append(keys, occurrenceKey(limitKey(alertRule), groupKey, alert))
+	}
+	return keys
+}
+
+// seenBefore returns true if every key was already acted on, otherwise it
+// records the keys and returns false
+func (d *deduplicator) seenBefore(keys []string, now time.Time) bool {
+	if len(keys) == 0 {
+		return false
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for key, at := range d.seen {
+		if now.Sub(at) >= d.ttl {
+			delete(d.seen, key)
+		}
+	}
+
+	duplicate := true
+	for _, key := range keys {
+		if _, ok := d.seen[key]; !ok {
+			duplicate = false
+			break
+		}
+	}
+	if duplicate {
+		return true
+	}
+	for _, key := range keys {
+		d.seen[key] = now
+	}
+	return false
+}
+
+// forget removes keys recorded by seenBefore, for when the action could not be queued
+func (d *deduplicator) forget(keys []string, at time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, key := range keys {
+		if seen, ok := d.seen[key]; ok && seen.Equal(at) {
+			delete(d.seen, key)
+		}
+	}
+}
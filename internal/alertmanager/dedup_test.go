@@ -0,0 +1,175 @@
+package alertmanager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/USA-RedDragon/metrics-actioner/internal/alertmanager/models"
+	"github.com/USA-RedDragon/metrics-actioner/internal/config"
+)
+
+func TestDeduplicatorSeenBefore(t *testing.T) {
+	t.Parallel()
+
+	type call struct {
+		keys  []string
+		after time.Duration
+		want  bool
+	}
+	tests := []struct {
+		name  string
+		calls []call
+	}{
+		{
+			name: "repeated occurrence",
+			calls: []call{
+				{keys: []string{"a"}},
+				{keys: []string{"a"}, after: time.Minute, want: true},
+			},
+		},
+		{
+			name: "different occurrence",
+			calls: []call{
+				{keys: []string{"a"}},
+				{keys: []string{"b"}, after: time.Minute},
+			},
+		},
+		{
+			// Groups are acted on again when any of their alerts is new
+			name: "group with a new alert",
+			calls: []call{
+				{keys: []string{"a", "b"}},
+				{keys: []string{"a", "b", "c"}, after: time.Minute},
+				{keys: []string{"a", "c"}, after: 2 * time.Minute, want: true},
+			},
+		},
+		{
+			name: "expired occurrence",
+			calls: []call{
+				{keys: []string{"a"}},
+				{keys: []string{"a"}, after: time.Hour},
+			},
+		},
+		{
+			name: "no keys",
+			calls: []call{
+				{keys: nil},
+				{keys: nil, after: time.Minute},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			d := newDeduplicator(time.Hour)
+			start := time.Now()
+			for i, c := range tt.calls {
+				if got := d.seenBefore(c.keys, start.Add(c.after)); got != c.want {
+					t.Errorf("call %d: seenBefore(%v) = %v, want %v", i, c.keys, got, c.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDeduplicatorForget(t *testing.T) {
+	t.Parallel()
+
+	d := newDeduplicator(time.Hour)
+	now := time.Now()
+	d.seenBefore([]string{"a"}, now)
+	// Only the keys recorded at the given time are forgotten
+	d.forget([]string{"a"}, now.Add(time.Second))
+	if !d.seenBefore([]string{"a"}, now.Add(time.Minute)) {
+		t.Fatal("forgot a key recorded at another time")
+	}
+	d.forget([]string{"a"}, now)
+	if d.seenBefore([]string{"a"}, now.Add(2*time.Minute)) {
+		t.Error("didn't forget the key")
+	}
+}
+
+func TestOccurrenceKeys(t *testing.T) {
+	t.Parallel()
+
+	startsAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	alert := func(fingerprint string, status models.AlertStatus, startsAt time.Time) models.Alert {
+		return models.Alert{Fingerprint: fingerprint, Status: status, StartsAt: startsAt}
+	}
+	webhook := func(groupKey string, alerts ...models.Alert) *models.Webhook {
+		return &models.Webhook{GroupKey: groupKey, Status: string(models.AlertStatusFiring), Alerts: alerts}
+	}
+	keys := func(data *TemplateData) []string {
+		return occurrenceKeys(&rule{cfg: config.Action{Name: "r"}}, data)
+	}
+	alertData := func(w *models.Webhook) *TemplateData {
+		return newAlertTemplateData(w, &w.Alerts[0])
+	}
+
+	tests := []struct {
+		name string
+		a, b *TemplateData
+		same bool
+	}{
+		{
+			name: "alert in different groups",
+			a:    alertData(webhook("g1", alert("f", models.AlertStatusFiring, startsAt))),
+			b:    alertData(webhook("g2", alert("f", models.AlertStatusFiring, startsAt))),
+			same: true,
+		},
+		{
+			name: "group with different keys",
+			a:    newGroupTemplateData(webhook("g1", alert("f", models.AlertStatusFiring, startsAt))),
+			b:    newGroupTemplateData(webhook("g2", alert("f", models.AlertStatusFiring, startsAt))),
+		},
+		{
+			name: "alert firing again",
+			a:    alertData(webhook("g", alert("f", models.AlertStatusFiring, startsAt))),
+			b:    alertData(webhook("g", alert("f", models.AlertStatusFiring, startsAt.Add(time.Hour)))),
+		},
+		{
+			name: "alert resolved",
+			a:    alertData(webhook("g", alert("f", models.AlertStatusFiring, startsAt))),
+			b:    alertData(webhook("g", alert("f", models.AlertStatusResolved, startsAt))),
+		},
+		{
+			name: "same start time in another zone",
+			a:    alertData(webhook("g", alert("f", models.AlertStatusFiring, startsAt))),
+			b:    alertData(webhook("g", alert("f", models.AlertStatusFiring, startsAt.In(time.FixedZone("CET", 3600))))),
+			same: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			a, b := keys(tt.a), keys(tt.b)
+			if len(a) != 1 || len(b) != 1 {
+				t.Fatalf("got keys %q and %q, want one each", a, b)
+			}
+			if same := a[0] == b[0]; same != tt.same {
+				t.Errorf("same occurrence = %v, want %v", same, tt.same)
+			}
+		})
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		t.Parallel()
+
+		data := alertData(webhook("g", alert("f", models.AlertStatusFiring, startsAt)))
+		dryRun := occurrenceKeys(&rule{cfg: config.Action{Name: "r"}, dryRun: true}, data)
+		if live := keys(data); dryRun[0] == live[0] {
+			t.Error("dry runs share the occurrence keys of real executions")
+		}
+	})
+
+	t.Run("alerts without fingerprints", func(t *testing.T) {
+		t.Parallel()
+
+		w := webhook("g", alert("", models.AlertStatusFiring, startsAt), alert("f", models.AlertStatusFiring, startsAt))
+		if got := keys(newGroupTemplateData(w)); len(got) != 1 {
+			t.Errorf("got %d keys, want 1", len(got))
+		}
+	})
+}
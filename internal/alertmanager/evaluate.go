@@ -0,0 +1,61 @@
+package alertmanager
+
+import (
+	"github.com/USA-RedDragon/metrics-actioner/internal/alertmanager/models"
+)
+
+// RuleEvaluation is the outcome of matching a rule against a webhook
+type RuleEvaluation struct {
+	Rule   string
+	Action string
+	DryRun bool
+	// Matches are the group or alerts the action would be executed for
+	Matches []Match
+	// Mismatches are the group or alerts the rule doesn't match, with the reason
+	Mismatches []Mismatch
+}
+
+// Match is a group or alert matched by a rule
+type Match struct {
+	// Alert is the matched alert in the alert match mode, nil for the group
+	Alert *models.Alert
+	// Options are the rendered options of the action
+	Options map[string]string
+	// Error is why the options failed to render
+	Error error
+}
+
+// Mismatch is a group or alert a rule doesn't match
+type Mismatch struct {
+	// Alert is the alert in the alert match mode, nil for the group
+	Alert  *models.Alert
+	Reason string
+}
+
+// Evaluate matches the webhook against the rules like ReceiveWebhook and
+// renders the matching actions' options, without executing anything or
+// taking cooldowns, execution limits or deduplication into account
+func (r *Receiver) Evaluate(webhook *models.Webhook) []RuleEvaluation {
+	rules := r.rules.Load().rules
+	evaluations := make([]RuleEvaluation, 0, len(rules))
+	for _, alertRule := range rules {
+		evaluation := RuleEvaluation{
+			Rule:   alertRule.cfg.Name,
+			Action: alertRule.cfg.Action,
+			DryRun: alertRule.dryRun,
+		}
+		matches, mismatches := matchWebhook(alertRule, webhook)
+		evaluation.Mismatches = mismatches
+		for _, match := range matches {
+			if match.revert {
+				reason := "revert_on_resolve: the resolved alert reverts what the action did when it fired, if anything"
+				evaluation.Mismatches = append(evaluation.Mismatches, Mismatch{Alert: match.data.Alert, Reason: reason})
+				continue
+			}
+			options, err := alertRule.options.render(match.data)
+			evaluation.Matches = append(evaluation.Matches, Match{Alert: match.data.Alert, Options: options, Error: err})
+		}
+		evaluations = append(evaluations, evaluation)
+	}
+	return evaluations
+}
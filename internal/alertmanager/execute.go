@@ -0,0 +1,253 @@
+package alertmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"time"
+
+	"github.com/USA-RedDragon/metrics-actioner/internal/alertmanager/actions"
+	"github.com/USA-RedDragon/metrics-actioner/internal/alertmanager/models"
+	"github.com/USA-RedDragon/metrics-actioner/internal/history"
+	"github.com/USA-RedDragon/metrics-actioner/internal/metrics"
+	"github.com/USA-RedDragon/metrics-actioner/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// matchedAlerts returns the alerts an action is executed for: the matched alert
+// in the alert match mode, otherwise the group's alerts with the webhook's status
+func matchedAlerts(data *TemplateData) []*models.Alert {
+	if data.Alert != nil {
+		return []*models.Alert{data.Alert}
+	}
+	var alerts []*models.Alert
+	for i := range data.Webhook.Alerts {
+		if string(data.Webhook.Alerts[i].Status) == data.Webhook.Status {
+			alerts = append(alerts, &data.Webhook.Alerts[i])
+		}
+	}
+	return alerts
+}
+
+func newExecution(alertRule *rule, data *TemplateData, trigger history.Trigger, now time.Time) *history.Execution {
+	execution := &history.Execution{
+		ID:        history.NewID(now),
+		Rule:      alertRule.cfg.Name,
+		Action:    alertRule.cfg.Action,
+		Trigger:   trigger,
+		AlertName: data.Labels["alertname"],
+		GroupKey:  data.Webhook.GroupKey,
+		CreatedAt: now,
+	}
+	for _, alert := range matchedAlerts(data) {
+		if alert.Fingerprint != "" {
+			execution.Fingerprints = append(execution.Fingerprints, alert.Fingerprint)
+		}
+	}
+	return execution
+}
+
+func (r *Receiver) record(execution *history.Execution) {
+	if err := r.history.Save(execution); err != nil {
+		slog.Error("Failed to record execution", "id", execution.ID, "rule", execution.Rule, "error", err.Error())
+	}
+}
+
+// enqueue renders the rule's options and queues the action, returning the
+// recorded execution. Duplicates aren't recorded and return ErrDuplicate.
+func (r *Receiver) enqueue(ctx context.Context, alertRule *rule, data *TemplateData, trigger history.Trigger) (*history.Execution, error) {
+	now := time.Now()
+	execution := newExecution(alertRule, data, trigger, now)
+	execution.DryRun = alertRule.dryRun
+
+	options, err := alertRule.options.render(data)
+	if err != nil {
+		// A template that fails to render for this alert won't render on a retry either,
+		// so report it and carry on with the other rules
+		slog.Error("Failed to render action options", "rule", alertRule.cfg.Name, "action", alertRule.cfg.Action, "error", err.Error())
+		execution.Status = history.StatusFailed
+		execution.Error = err.Error()
+		metrics.ActionExecutions.WithLabelValues(alertRule.cfg.Name, alertRule.cfg.Action, string(execution.Status)).Inc()
+		r.record(execution)
+		return execution, nil
+	}
+	execution.Options = options
+
+	keys := occurrenceKeys(alertRule, data)
+	if r.deduplicator.seenBefore(keys, now) {
+		// Duplicates aren't recorded, the original execution already is
+		metrics.ActionsSuppressed.WithLabelValues(alertRule.cfg.Name, alertRule.cfg.Action, suppressedDuplicate).Inc()
+		slog.Info("Suppressed action", "rule", alertRule.cfg.Name, "action", alertRule.cfg.Action, "reason", suppressedDuplicate, "groupKey", data.Webhook.GroupKey)
+		return nil, ErrDuplicate
+	}
+	target := target(alertRule, options, data)
+	if reason := r.limiter.allow(alertRule, target, now); reason != "" {
+		// The occurrence wasn't acted on, so a later notification may still act on it
+		r.deduplicator.forget(keys, now)
+		metrics.ActionsSuppressed.WithLabelValues(alertRule.cfg.Name, alertRule.cfg.Action, reason).Inc()
+		slog.Info("Suppressed action", "rule", alertRule.cfg.Name, "action", alertRule.cfg.Action, "reason", reason, "target", target)
+		execution.Status = history.StatusSuppressed
+		execution.Error = reason
+		r.record(execution)
+		return execution, nil
+	}
+
+	// Record before queueing, as the execution belongs to the worker once queued
+	execution.Status = history.StatusQueued
+	r.record(execution)
+	queued := *execution
+	// Track the revert before the worker can finish the execution
+	r.trackRevert(alertRule, data, execution, options)
+	err = r.queue.enqueue(&job{
+		rule:      alertRule,
+		data:      data,
+		options:   options,
+		dryRun:    execution.DryRun,
+		execution: execution,
+		// The action's span continues the webhook's trace
+		spanContext: trace.SpanContextFromContext(ctx),
+	})
+	if err != nil {
+		r.deduplicator.forget(keys, now)
+		r.limiter.undo(alertRule, target, now)
+		r.forgetRevert(alertRule, data, execution)
+		execution.Status = history.StatusFailed
+		execution.Error = err.Error()
+		r.record(execution)
+		return execution, err
+	}
+	return &queued, nil
+}
+
+func (r *Receiver) execute(ctx context.Context, j *job) {
+	ctx, span := tracing.Tracer("alertmanager").Start(trace.ContextWithSpanContext(ctx, j.spanContext), "ExecuteAction", trace.WithAttributes(
+		attribute.String("execution.id", j.execution.ID),
+		attribute.String("rule", j.rule.cfg.Name),
+		attribute.String("action", j.rule.cfg.Action),
+		attribute.String("alertname", j.execution.AlertName),
+		attribute.Bool("dry_run", j.dryRun),
+		attribute.Bool("revert", j.revert != nil),
+	))
+	defer span.End()
+
+	execution := j.execution
+	startedAt := time.Now()
+	execution.StartedAt = &startedAt
+	execution.Status = history.StatusRunning
+	r.record(execution)
+
+	output := &outputBuffer{}
+	state, err := r.executeWithRetries(ctx, j, output)
+
+	endedAt := time.Now()
+	execution.EndedAt = &endedAt
+	execution.Output = output.String()
+	span.SetAttributes(attribute.Int("attempts", execution.Attempts))
+	if err != nil {
+		tracing.RecordError(span, err)
+		execution.Status = history.StatusFailed
+		execution.Error = err.Error()
+		if j.revert != nil {
+			r.keepRevert(j)
+		}
+	} else {
+		execution.Status = history.StatusSucceeded
+		if _, ok := firingRevertKey(j.rule, j.data, j.dryRun); ok && j.revert == nil && len(state) > 0 {
+			// Recorded so the revert is restored after a restart
+			execution.RevertState = state
+		}
+		if !j.dryRun {
+			metrics.ActionLastSuccess.WithLabelValues(j.rule.cfg.Name, j.rule.cfg.Action).Set(float64(endedAt.Unix()))
+		}
+	}
+	metrics.ActionExecutions.WithLabelValues(j.rule.cfg.Name, j.rule.cfg.Action, string(execution.Status)).Inc()
+	metrics.ActionExecutionDuration.WithLabelValues(j.rule.cfg.Name, j.rule.cfg.Action, string(execution.Status)).Observe(endedAt.Sub(startedAt).Seconds())
+	r.record(execution)
+	r.finishRevert(j, state)
+}
+
+// dropped records a queued job dropped on shutdown as cancelled
+func (r *Receiver) dropped(j *job) {
+	if j.revert != nil {
+		r.keepRevert(j)
+	} else {
+		r.forgetRevert(j.rule, j.data, j.execution)
+	}
+	endedAt := time.Now()
+	j.execution.EndedAt = &endedAt
+	j.execution.Status = history.StatusCancelled
+	j.execution.Error = "dropped on shutdown before it ran"
+	metrics.ActionExecutions.WithLabelValues(j.rule.cfg.Name, j.rule.cfg.Action, string(j.execution.Status)).Inc()
+	r.record(j.execution)
+}
+
+// executeWithRetries returns the state saved by the attempts once one succeeds
+func (r *Receiver) executeWithRetries(ctx context.Context, j *job, output *outputBuffer) (map[string]string, error) {
+	retry := &j.rule.cfg.Retry
+	// The state is shared by the attempts, so a retry sees what an earlier one changed
+	state := make(map[string]string)
+	for attempt := 1; ; attempt++ {
+		j.execution.Attempts = attempt
+		slog.Info("Executing action", "id", j.execution.ID, "rule", j.rule.cfg.Name, "action", j.rule.cfg.Action, "attempt", attempt, "dryRun", j.dryRun)
+		err := r.attempt(ctx, j, state, output)
+		if err == nil {
+			metrics.ActionAttempts.WithLabelValues(j.rule.cfg.Name, j.rule.cfg.Action, "success").Inc()
+			slog.Info("Action succeeded", "id", j.execution.ID, "rule", j.rule.cfg.Name, "action", j.rule.cfg.Action, "attempt", attempt, "dryRun", j.dryRun)
+			return state, nil
+		}
+		metrics.ActionAttempts.WithLabelValues(j.rule.cfg.Name, j.rule.cfg.Action, "failure").Inc()
+
+		// Don't retry once we're shutting down
+		if attempt >= retry.MaxAttempts || ctx.Err() != nil || !isRetryable(retry, err) {
+			slog.Error("Action failed", "id", j.execution.ID, "rule", j.rule.cfg.Name, "action", j.rule.cfg.Action, "attempt", attempt, "error", err.Error())
+			return nil, err
+		}
+		delay := backoff(retry, attempt)
+		slog.Warn("Action failed, retrying", "id", j.execution.ID, "rule", j.rule.cfg.Name, "action", j.rule.cfg.Action, "attempt", attempt, "backoff", delay, "error", err.Error())
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			slog.Error("Action cancelled while waiting to retry", "id", j.execution.ID, "rule", j.rule.cfg.Name, "action", j.rule.cfg.Action, "attempt", attempt)
+			return nil, errors.Join(err, ctx.Err())
+		}
+	}
+}
+
+// attempt executes, or reverts, the action once, bounded by the rule's
+// timeout. The action saves its state in state.
+func (r *Receiver) attempt(ctx context.Context, j *job, state map[string]string, output *outputBuffer) error {
+	ctx, span := tracing.Tracer("alertmanager").Start(ctx, "Attempt", trace.WithAttributes(
+		attribute.Int("attempt", j.execution.Attempts),
+	))
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(j.rule.cfg.Timeout))
+	defer cancel()
+	req := &actions.Request{
+		Webhook: j.data.Webhook,
+		Labels:  j.data.Labels,
+		Options: j.options,
+		DryRun:  j.dryRun,
+		State:   state,
+		Output:  output,
+	}
+	var err error
+	switch reversible, ok := j.rule.action.(ReversibleActionIface); {
+	case j.revert == nil:
+		err = j.rule.action.Execute(ctx, req)
+	case !ok:
+		// Validation only allows revert_on_resolve for reversible actions
+		err = fmt.Errorf("%w: %s", errNotReversible, j.rule.cfg.Action)
+	default:
+		maps.Copy(req.State, j.revert.state)
+		err = reversible.Revert(ctx, req)
+	}
+	if err != nil {
+		tracing.RecordError(span, err)
+		return err
+	}
+	return nil
+}
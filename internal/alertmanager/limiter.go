@@ -0,0 +1,121 @@
+package alertmanager
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/USA-RedDragon/metrics-actioner/internal/alertmanager/actions"
+)
+
+const (
+	suppressedCooldown  = "cooldown"
+	suppressedRateLimit = "rate_limit"
+)
+
+// limiter enforces the rules' cooldowns and execution limits
+type limiter struct {
+	mu sync.Mutex
+	// lastExecution of each target, keyed by limitKey and target options
+	lastExecution map[string]time.Time
+	// executions of each rule within its max_executions window, keyed by limitKey
+	executions map[string][]time.Time
+}
+
+func newLimiter() *limiter {
+	return &limiter{
+		lastExecution: make(map[string]time.Time),
+		executions:    make(map[string][]time.Time),
+	}
+}
+
+// limitKey scopes the deduplication and limits of a rule. Dry runs are
+// kept apart so that they don't use up what real executions are allowed.
+func limitKey(alertRule *rule) string {
+	if alertRule.dryRun {
+		return alertRule.cfg.Name + "\x00dry_run"
+	}
+	return alertRule.cfg.Name
+}
+
+// target returns the options identifying what the rule's action will act
+// on, which are the rendered options with the defaults the action takes
+// from the alert's labels
+func target(alertRule *rule, options map[string]string, data *TemplateData) map[string]string {
+	targeted, ok := alertRule.action.(TargetedActionIface)
+	if !ok {
+		return options
+	}
+	return targeted.Target(&actions.Request{
+		Webhook: data.Webhook,
+		Labels:  data.Labels,
+		Options: options,
+	})
+}
+
+// targetKey identifies what an action will act on by its target options
+func targetKey(ruleName string, target map[string]string) string {
+	var sb strings.Builder
+	sb.WriteString(ruleName)
+	for _, key := range slices.Sorted(maps.Keys(target)) {
+		fmt.Fprintf(&sb, "\x00%s=%s", key, target[key])
+	}
+	return sb.String()
+}
+
+// allow records an execution of the rule against the target and returns an
+// empty reason, or returns why the execution should be suppressed
+func (l *limiter) allow(alertRule *rule, target map[string]string, now time.Time) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	name := limitKey(alertRule)
+	cooldown := time.Duration(alertRule.cfg.Cooldown)
+	key := targetKey(name, target)
+	if cooldown > 0 {
+		if last, ok := l.lastExecution[key]; ok && now.Sub(last) < cooldown {
+			return suppressedCooldown
+		}
+	}
+
+	if maxExecutions := alertRule.cfg.MaxExecutions; maxExecutions != nil {
+		window := time.Duration(maxExecutions.Window)
+		executions := slices.DeleteFunc(l.executions[name], func(t time.Time) bool {
+			return now.Sub(t) >= window
+		})
+		l.executions[name] = executions
+		if len(executions) >= maxExecutions.Count {
+			return suppressedRateLimit
+		}
+		l.executions[name] = append(executions, now)
+	}
+
+	if cooldown > 0 {
+		l.lastExecution[key] = now
+		// Forget targets whose cooldown has expired
+		for k, last := range l.lastExecution {
+			if strings.HasPrefix(k, name+"\x00") && now.Sub(last) >= cooldown {
+				delete(l.lastExecution, k)
+			}
+		}
+	}
+	return ""
+}
+
+// undo forgets an execution recorded by allow, for when it could not be queued
+func (l *limiter) undo(alertRule *rule, target map[string]string, at time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	name := limitKey(alertRule)
+	key := targetKey(name, target)
+	if last, ok := l.lastExecution[key]; ok && last.Equal(at) {
+		delete(l.lastExecution, key)
+	}
+	l.executions[name] = slices.DeleteFunc(l.executions[name], func(t time.Time) bool {
+		return t.Equal(at)
+	})
+}
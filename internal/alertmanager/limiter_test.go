@@ -0,0 +1,178 @@
+package alertmanager
+
+import (
+	"maps"
+	"testing"
+	"time"
+
+	"github.com/USA-RedDragon/metrics-actioner/internal/alertmanager/actions"
+	"github.com/USA-RedDragon/metrics-actioner/internal/alertmanager/models"
+	"github.com/USA-RedDragon/metrics-actioner/internal/config"
+)
+
+func TestLimiterAllow(t *testing.T) {
+	t.Parallel()
+
+	type call struct {
+		target map[string]string
+		after  time.Duration
+		want   string
+	}
+	podA := map[string]string{"namespace": "prod", "pod": "a"}
+	podB := map[string]string{"namespace": "prod", "pod": "b"}
+	tests := []struct {
+		name  string
+		cfg   config.Action
+		calls []call
+	}{
+		{
+			name: "no limits",
+			cfg:  config.Action{Name: "r"},
+			calls: []call{
+				{target: podA},
+				{target: podA},
+			},
+		},
+		{
+			name: "cooldown per target",
+			cfg:  config.Action{Name: "r", Cooldown: config.Duration(time.Minute)},
+			calls: []call{
+				{target: podA},
+				{target: podA, after: 30 * time.Second, want: suppressedCooldown},
+				{target: podB, after: 30 * time.Second},
+				{target: podA, after: time.Minute},
+			},
+		},
+		{
+			name: "max executions across targets",
+			cfg:  config.Action{Name: "r", MaxExecutions: &config.MaxExecutions{Count: 2, Window: config.Duration(time.Hour)}},
+			calls: []call{
+				{target: podA},
+				{target: podB, after: time.Minute},
+				{target: podA, after: 2 * time.Minute, want: suppressedRateLimit},
+				// The first execution left the window
+				{target: podA, after: time.Hour},
+			},
+		},
+		{
+			name: "suppressed executions don't count",
+			cfg: config.Action{
+				Name:          "r",
+				Cooldown:      config.Duration(time.Minute),
+				MaxExecutions: &config.MaxExecutions{Count: 2, Window: config.Duration(time.Hour)},
+			},
+			calls: []call{
+				{target: podA},
+				{target: podA, after: time.Second, want: suppressedCooldown},
+				{target: podB, after: 2 * time.Second},
+				{target: podB, after: 2 * time.Minute, want: suppressedRateLimit},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			l := newLimiter()
+			alertRule := &rule{cfg: tt.cfg}
+			start := time.Now()
+			for i, c := range tt.calls {
+				if got := l.allow(alertRule, c.target, start.Add(c.after)); got != c.want {
+					t.Errorf("call %d: allow(%v) = %q, want %q", i, c.target, got, c.want)
+				}
+			}
+		})
+	}
+}
+
+func TestLimiterDryRuns(t *testing.T) {
+	t.Parallel()
+
+	l := newLimiter()
+	cfg := config.Action{
+		Name:          "r",
+		Cooldown:      config.Duration(time.Minute),
+		MaxExecutions: &config.MaxExecutions{Count: 1, Window: config.Duration(time.Hour)},
+	}
+	live := &rule{cfg: cfg}
+	dryRun := &rule{cfg: cfg, dryRun: true}
+	target := map[string]string{"deployment": "web"}
+	now := time.Now()
+	// Dry runs don't use up the limits of real executions, nor the other way round
+	for i, alertRule := range []*rule{dryRun, live} {
+		if reason := l.allow(alertRule, target, now); reason != "" {
+			t.Errorf("call %d: allow = %q, want it allowed", i, reason)
+		}
+	}
+	if reason := l.allow(dryRun, target, now.Add(time.Second)); reason != suppressedCooldown {
+		t.Errorf("second dry run allow = %q, want %q", reason, suppressedCooldown)
+	}
+}
+
+func TestLimiterUndo(t *testing.T) {
+	t.Parallel()
+
+	l := newLimiter()
+	alertRule := &rule{cfg: config.Action{
+		Name:          "r",
+		Cooldown:      config.Duration(time.Minute),
+		MaxExecutions: &config.MaxExecutions{Count: 1, Window: config.Duration(time.Hour)},
+	}}
+	target := map[string]string{"deployment": "web"}
+	now := time.Now()
+	if reason := l.allow(alertRule, target, now); reason != "" {
+		t.Fatalf("allow = %q, want it allowed", reason)
+	}
+	l.undo(alertRule, target, now)
+	if reason := l.allow(alertRule, target, now.Add(time.Second)); reason != "" {
+		t.Errorf("allow after undo = %q, want it allowed", reason)
+	}
+}
+
+func TestTarget(t *testing.T) {
+	t.Parallel()
+
+	labels := models.Labels{"namespace": "prod", "pod": "web-1", "deployment": "web"}
+	tests := []struct {
+		name    string
+		action  ActionIface
+		options map[string]string
+		want    map[string]string
+	}{
+		{
+			name:    "untargeted action",
+			action:  &actions.SSH{},
+			options: map[string]string{"host": "db-1", "command": "systemctl restart db"},
+			want:    map[string]string{"host": "db-1", "command": "systemctl restart db"},
+		},
+		{
+			name:    "pod defaulted from the labels",
+			action:  &actions.DeletePod{},
+			options: map[string]string{},
+			want:    map[string]string{"namespace": "prod", "pod": "web-1"},
+		},
+		{
+			name:    "selector instead of the pod",
+			action:  &actions.DeletePod{},
+			options: map[string]string{"selector": "app=web"},
+			want:    map[string]string{"namespace": "prod", "selector": "app=web"},
+		},
+		{
+			name:    "options override the labels",
+			action:  &actions.EvictPod{},
+			options: map[string]string{"namespace": "staging", "pod": "web-2"},
+			want:    map[string]string{"namespace": "staging", "pod": "web-2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			data := &TemplateData{Webhook: &models.Webhook{}, Labels: labels}
+			got := target(&rule{action: tt.action}, tt.options, data)
+			if !maps.Equal(got, tt.want) {
+				t.Errorf("target = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
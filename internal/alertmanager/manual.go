@@ -0,0 +1,153 @@
+package alertmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/USA-RedDragon/metrics-actioner/internal/alertmanager/models"
+	"github.com/USA-RedDragon/metrics-actioner/internal/config"
+	"github.com/USA-RedDragon/metrics-actioner/internal/history"
+)
+
+var (
+	ErrRuleNotFound = errors.New("rule not found")
+	ErrNoMatch      = errors.New("the labels don't match the rule")
+	ErrInvalidRun   = errors.New("invalid run")
+	// ErrActionNotAllowed is returned when running an action that isn't in http.manual_runs.allowed_actions
+	ErrActionNotAllowed = errors.New("action can't be run directly")
+)
+
+// ManualRun runs a rule or action on demand, as if a single alert with the
+// given labels had been received
+type ManualRun struct {
+	Labels      models.Labels      `json:"labels"`
+	Annotations models.Annotations `json:"annotations"`
+	// Status of the alert, firing unless set
+	Status models.AlertStatus `json:"status"`
+	// Options of the action when running an action directly. They are
+	// templates like the options of rules.
+	Options map[string]string `json:"options"`
+	// DryRun runs the action in dry run mode. The global and rule dry run
+	// settings can't be overridden.
+	DryRun bool `json:"dryRun"`
+}
+
+func (m *ManualRun) validate() error {
+	switch m.Status {
+	case "", models.AlertStatusFiring, models.AlertStatusResolved:
+		return nil
+	default:
+		return fmt.Errorf("%w: status must be firing or resolved", ErrInvalidRun)
+	}
+}
+
+// webhook returns a webhook with the run's alert
+func (m *ManualRun) webhook(now time.Time) *models.Webhook {
+	status := m.Status
+	if status == "" {
+		status = models.AlertStatusFiring
+	}
+	labels := m.Labels
+	if labels == nil {
+		labels = models.Labels{}
+	}
+	annotations := m.Annotations
+	if annotations == nil {
+		annotations = models.Annotations{}
+	}
+	return &models.Webhook{
+		Version:           "4",
+		Status:            string(status),
+		Receiver:          string(history.TriggerManual),
+		GroupLabels:       labels,
+		CommonLabels:      labels,
+		CommonAnnotations: annotations,
+		Alerts: []models.Alert{{
+			Status:      status,
+			Labels:      labels,
+			Annotations: annotations,
+			StartsAt:    now,
+		}},
+	}
+}
+
+// RunRule matches the rule against the run's labels and queues its action,
+// subject to the rule's safety limits like a webhook would be
+func (r *Receiver) RunRule(ctx context.Context, name string, run *ManualRun) ([]*history.Execution, error) {
+	if err := run.validate(); err != nil {
+		return nil, err
+	}
+	var alertRule *rule
+	for _, candidate := range r.rules.Load().rules {
+		if candidate.cfg.Name == name {
+			alertRule = candidate
+			break
+		}
+	}
+	if alertRule == nil {
+		return nil, fmt.Errorf("%w: %s", ErrRuleNotFound, name)
+	}
+	if run.DryRun && !alertRule.dryRun {
+		dryRunRule := *alertRule
+		dryRunRule.dryRun = true
+		alertRule = &dryRunRule
+	}
+
+	webhook := run.webhook(time.Now())
+	if reason := mismatch(alertRule, webhook.GroupLabels, webhook.Status, webhook.CommonLabels); reason != "" {
+		return nil, fmt.Errorf("%w: %s", ErrNoMatch, reason)
+	}
+	executions, err := r.matchRule(ctx, alertRule, webhook, history.TriggerManual)
+	if err != nil {
+		return executions, err
+	}
+	return executions, nil
+}
+
+// RunAction queues an action allowed by http.manual_runs.allowed_actions
+// with the run's options, which are rendered against the run's labels.
+// The action runs with the default timeout and retry settings of rules and
+// the manual runs' cooldown and execution limits, under the rule name
+// manual:<action>.
+func (r *Receiver) RunAction(ctx context.Context, actionName string, run *ManualRun) (*history.Execution, error) {
+	if err := run.validate(); err != nil {
+		return nil, err
+	}
+	action, err := r.FindAction(actionName)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(r.manualRuns.AllowedActions, actionName) {
+		return nil, fmt.Errorf("%w: %s isn't in http.manual_runs.allowed_actions", ErrActionNotAllowed, actionName)
+	}
+	if err := action.OptionSchema().Validate("options", run.Options); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRun, err)
+	}
+
+	actionRule, err := r.newRule(config.Action{
+		Name:      config.ManualRuleNamePrefix + actionName,
+		Action:    actionName,
+		MatchMode: config.MatchModeAlert,
+		On:        config.OnBoth,
+		Options:   run.Options,
+		Timeout:   config.DefaultActionTimeout,
+		Retry: config.Retry{
+			MaxAttempts:    config.DefaultRetryMaxAttempts,
+			InitialBackoff: config.DefaultRetryInitialBackoff,
+			MaxBackoff:     config.DefaultRetryMaxBackoff,
+			RetryOn:        config.DefaultRetryOn,
+		},
+		Cooldown:      r.manualRuns.Cooldown,
+		MaxExecutions: r.manualRuns.MaxExecutions,
+		DryRun:        run.DryRun,
+	}, r.rules.Load().dryRun)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRun, err)
+	}
+
+	webhook := run.webhook(time.Now())
+	return r.enqueue(ctx, actionRule, newAlertTemplateData(webhook, &webhook.Alerts[0]), history.TriggerManual)
+}
@@ -0,0 +1,38 @@
+package alertmanager
+
+import (
+	"bytes"
+	"sync"
+)
+
+// maxOutputSize is the amount of action output kept in the execution history
+const maxOutputSize = 64 * 1024
+
+// outputBuffer captures the output of an action, up to maxOutputSize
+type outputBuffer struct {
+	mu        sync.Mutex
+	buf       bytes.Buffer
+	truncated bool
+}
+
+func (b *outputBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	remaining := maxOutputSize - b.buf.Len()
+	if len(p) > remaining {
+		b.buf.Write(p[:remaining])
+		b.truncated = true
+		return len(p), nil
+	}
+	b.buf.Write(p)
+	return len(p), nil
+}
+
+func (b *outputBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.truncated {
+		return b.buf.String() + "\n[output truncated]"
+	}
+	return b.buf.String()
+}
@@ -0,0 +1,162 @@
+package alertmanager
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/USA-RedDragon/metrics-actioner/internal/config"
+	"github.com/USA-RedDragon/metrics-actioner/internal/history"
+	"github.com/USA-RedDragon/metrics-actioner/internal/metrics"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	ErrQueueFull    = errors.New("action queue is full")
+	ErrQueueStopped = errors.New("action queue is stopped")
+)
+
+// job is a matched rule waiting to be executed
+type job struct {
+	rule      *rule
+	data      *TemplateData
+	options   map[string]string
+	dryRun    bool
+	execution *history.Execution
+	// revert is set when the job reverts a previous execution
+	revert *pendingRevert
+	// spanContext is the span of the webhook that matched the rule
+	spanContext trace.SpanContext
+}
+
+// queue executes jobs on a bounded pool of workers, limiting the
+// concurrency of individual action types as configured. Jobs whose action
+// type is at its limit wait without taking a worker, so that other action
+// types can still run.
+type queue struct {
+	config  *config.Workers
+	execute func(context.Context, *job)
+	// drop is called for the jobs dropped on shutdown
+	drop    func(*job)
+	ctx     context.Context //nolint:golint,containedctx
+	cancel  context.CancelFunc
+	waitGrp sync.WaitGroup
+
+	mu sync.Mutex
+	// pending jobs in the order they were queued
+	pending []*job
+	// running jobs, in total and per action type
+	running         int
+	runningByAction map[string]int
+	started         bool
+	stopped         bool
+}
+
+func newQueue(config *config.Workers, execute func(context.Context, *job), drop func(*job)) *queue {
+	// Cancelled on shutdown to abort in-flight actions
+	ctx, cancel := context.WithCancel(context.Background())
+	return &queue{
+		config:          config,
+		execute:         execute,
+		drop:            drop,
+		ctx:             ctx,
+		cancel:          cancel,
+		runningByAction: make(map[string]int),
+	}
+}
+
+func (q *queue) start() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.started = true
+	q.dispatch()
+	slog.Info("Action workers started", "concurrency", q.config.Concurrency, "queueSize", q.config.QueueSize)
+}
+
+// dispatch starts the oldest pending jobs whose action type is below its
+// limit while workers are available. q.mu must be held.
+func (q *queue) dispatch() {
+	if !q.started {
+		return
+	}
+	for q.running < q.config.Concurrency {
+		i := slices.IndexFunc(q.pending, func(j *job) bool {
+			limit, ok := q.config.ActionConcurrency[j.rule.cfg.Action]
+			return !ok || q.runningByAction[j.rule.cfg.Action] < limit
+		})
+		if i < 0 {
+			break
+		}
+		j := q.pending[i]
+		q.pending = slices.Delete(q.pending, i, i+1)
+		q.running++
+		q.runningByAction[j.rule.cfg.Action]++
+		q.waitGrp.Add(1)
+		go q.run(j)
+	}
+	metrics.QueueDepth.Set(float64(len(q.pending)))
+}
+
+func (q *queue) run(j *job) {
+	defer q.waitGrp.Done()
+	if q.ctx.Err() != nil {
+		slog.Warn("Dropping queued action on shutdown", "rule", j.rule.cfg.Name, "action", j.rule.cfg.Action)
+		q.drop(j)
+	} else {
+		q.execute(q.ctx, j)
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.running--
+	q.runningByAction[j.rule.cfg.Action]--
+	q.dispatch()
+}
+
+// enqueue adds a job to the queue without blocking
+func (q *queue) enqueue(j *job) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.stopped {
+		return ErrQueueStopped
+	}
+	if len(q.pending) >= q.config.QueueSize {
+		return ErrQueueFull
+	}
+	q.pending = append(q.pending, j)
+	q.dispatch()
+	return nil
+}
+
+// stop stops accepting jobs and waits for the queued ones to finish.
+// Once ctx is done, in-flight actions are cancelled and the remaining jobs dropped.
+func (q *queue) stop(ctx context.Context) {
+	q.mu.Lock()
+	q.stopped = true
+	q.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		q.waitGrp.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		slog.Warn("Timed out draining action queue, cancelling in-flight actions")
+	}
+	q.cancel()
+	q.mu.Lock()
+	dropped := q.pending
+	q.pending = nil
+	metrics.QueueDepth.Set(0)
+	q.mu.Unlock()
+	for _, j := range dropped {
+		slog.Warn("Dropping queued action on shutdown", "rule", j.rule.cfg.Name, "action", j.rule.cfg.Action)
+		q.drop(j)
+	}
+	<-drained
+}
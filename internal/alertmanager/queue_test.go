@@ -0,0 +1,92 @@
+package alertmanager
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/USA-RedDragon/metrics-actioner/internal/config"
+)
+
+func TestQueueLimitedActionsDontBlockWorkers(t *testing.T) {
+	t.Parallel()
+
+	workers := &config.Workers{
+		Concurrency:       2,
+		QueueSize:         10,
+		ActionConcurrency: map[string]int{"ssh": 1},
+	}
+	release := make(chan struct{})
+	restarted := make(chan struct{})
+	var mu sync.Mutex
+	running := map[string]int{}
+	maxRunning := map[string]int{}
+	q := newQueue(workers, func(_ context.Context, j *job) {
+		action := j.rule.cfg.Action
+		mu.Lock()
+		running[action]++
+		maxRunning[action] = max(maxRunning[action], running[action])
+		mu.Unlock()
+		if action == "ssh" {
+			<-release
+		} else {
+			close(restarted)
+		}
+		mu.Lock()
+		running[action]--
+		mu.Unlock()
+	}, func(*job) {
+		t.Error("dropped a job")
+	})
+	q.start()
+
+	for range 3 {
+		if err := q.enqueue(&job{rule: &rule{cfg: config.Action{Action: "ssh"}}}); err != nil {
+			t.Fatalf("enqueue ssh: %v", err)
+		}
+	}
+	if err := q.enqueue(&job{rule: &rule{cfg: config.Action{Action: "rollout-restart-deployment"}}}); err != nil {
+		t.Fatalf("enqueue restart: %v", err)
+	}
+
+	select {
+	case <-restarted:
+	case <-time.After(5 * time.Second):
+		t.Fatal("restart waited behind the ssh jobs at their limit")
+	}
+	close(release)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	q.stop(ctx)
+
+	if maxRunning["ssh"] != 1 {
+		t.Errorf("ran %d ssh jobs at once, want 1", maxRunning["ssh"])
+	}
+}
+
+func TestQueueFullAndStopped(t *testing.T) {
+	t.Parallel()
+
+	var dropped []*job
+	q := newQueue(&config.Workers{Concurrency: 1, QueueSize: 1}, func(context.Context, *job) {
+		t.Error("ran a job before the queue started")
+	}, func(j *job) {
+		dropped = append(dropped, j)
+	})
+	// Jobs stay queued until the queue is started
+	if err := q.enqueue(&job{rule: &rule{}}); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if err := q.enqueue(&job{rule: &rule{}}); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("enqueue = %v, want %v", err, ErrQueueFull)
+	}
+	q.stop(context.Background())
+	if len(dropped) != 1 {
+		t.Errorf("dropped %d jobs on stop, want 1", len(dropped))
+	}
+	if err := q.enqueue(&job{rule: &rule{}}); !errors.Is(err, ErrQueueStopped) {
+		t.Fatalf("enqueue after stop = %v, want %v", err, ErrQueueStopped)
+	}
+}
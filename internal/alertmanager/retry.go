@@ -0,0 +1,70 @@
+package alertmanager
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"os"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/USA-RedDragon/metrics-actioner/internal/alertmanager/actions"
+	"github.com/USA-RedDragon/metrics-actioner/internal/config"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// classifyError returns the retry class of an error, or an empty class
+// when the error is not transient
+func classifyError(err error) config.RetryOn {
+	var netErr net.Error
+	switch {
+	// Evictions refused by a PodDisruptionBudget are also throttling errors,
+	// but usually take longer to clear and are retried separately
+	case errors.Is(err, actions.ErrDisruptionBudget):
+		return config.RetryOnDisruptionBudget
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, os.ErrDeadlineExceeded),
+		apierrors.IsTimeout(err),
+		apierrors.IsServerTimeout(err),
+		errors.As(err, &netErr) && netErr.Timeout():
+		return config.RetryOnTimeout
+	case apierrors.IsInternalError(err),
+		apierrors.IsServiceUnavailable(err),
+		apierrors.IsTooManyRequests(err),
+		apierrors.IsConflict(err),
+		apierrors.IsUnexpectedServerError(err):
+		return config.RetryOnServer
+	case errors.As(err, &netErr),
+		errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.ECONNRESET),
+		errors.Is(err, syscall.EHOSTUNREACH),
+		errors.Is(err, syscall.ENETUNREACH):
+		return config.RetryOnNetwork
+	}
+	return ""
+}
+
+func isRetryable(retry *config.Retry, err error) bool {
+	if slices.Contains(retry.RetryOn, config.RetryOnAny) {
+		return true
+	}
+	class := classifyError(err)
+	return class != "" && slices.Contains(retry.RetryOn, class)
+}
+
+// backoff returns the delay before the given retry attempt, doubling from the initial
+// backoff up to the max backoff and randomized by the jitter fraction
+func backoff(retry *config.Retry, attempt int) time.Duration {
+	delay := time.Duration(retry.InitialBackoff)
+	for i := 1; i < attempt && delay < time.Duration(retry.MaxBackoff); i++ {
+		delay *= 2
+	}
+	delay = min(delay, time.Duration(retry.MaxBackoff))
+	if retry.Jitter > 0 {
+		//nolint:golint,gosec
+		delay += time.Duration(float64(delay) * retry.Jitter * (2*rand.Float64() - 1))
+	}
+	return delay
+}
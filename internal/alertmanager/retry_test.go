@@ -0,0 +1,131 @@
+package alertmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/USA-RedDragon/metrics-actioner/internal/alertmanager/actions"
+	"github.com/USA-RedDragon/metrics-actioner/internal/config"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var errPermanent = errors.New("permanent")
+
+func TestClassifyError(t *testing.T) {
+	t.Parallel()
+
+	pods := schema.GroupResource{Resource: "pods"}
+	tests := []struct {
+		name string
+		err  error
+		want config.RetryOn
+	}{
+		{name: "disruption budget", err: fmt.Errorf("evicting: %w", actions.ErrDisruptionBudget), want: config.RetryOnDisruptionBudget},
+		{name: "context deadline", err: fmt.Errorf("scaling: %w", context.DeadlineExceeded), want: config.RetryOnTimeout},
+		{name: "i/o deadline", err: fmt.Errorf("reading: %w", os.ErrDeadlineExceeded), want: config.RetryOnTimeout},
+		{name: "connection timed out", err: &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ETIMEDOUT}, want: config.RetryOnTimeout},
+		{name: "dns timeout", err: &net.DNSError{Err: "timeout", Name: "db-1", IsTimeout: true}, want: config.RetryOnTimeout},
+		{name: "api timeout", err: apierrors.NewTimeoutError("slow", 1), want: config.RetryOnTimeout},
+		{name: "api server timeout", err: apierrors.NewServerTimeout(pods, "get", 1), want: config.RetryOnTimeout},
+		{name: "internal error", err: apierrors.NewInternalError(errPermanent), want: config.RetryOnServer},
+		{name: "service unavailable", err: apierrors.NewServiceUnavailable("down"), want: config.RetryOnServer},
+		{name: "too many requests", err: apierrors.NewTooManyRequests("slow down", 1), want: config.RetryOnServer},
+		{name: "conflict", err: apierrors.NewConflict(pods, "web-1", errPermanent), want: config.RetryOnServer},
+		{name: "connection refused", err: &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, want: config.RetryOnNetwork},
+		{name: "connection reset", err: fmt.Errorf("ssh: %w", syscall.ECONNRESET), want: config.RetryOnNetwork},
+		{name: "not found", err: apierrors.NewNotFound(pods, "web-1")},
+		{name: "forbidden", err: apierrors.NewForbidden(pods, "web-1", errPermanent)},
+		{name: "other", err: errPermanent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := classifyError(tt.err); got != tt.want {
+				t.Errorf("classifyError(%v) = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	t.Parallel()
+
+	timeout := fmt.Errorf("scaling: %w", context.DeadlineExceeded)
+	tests := []struct {
+		name    string
+		retryOn []config.RetryOn
+		err     error
+		want    bool
+	}{
+		{name: "matching class", retryOn: []config.RetryOn{config.RetryOnNetwork, config.RetryOnTimeout}, err: timeout, want: true},
+		{name: "other class", retryOn: []config.RetryOn{config.RetryOnServer}, err: timeout},
+		{name: "no classes", err: timeout},
+		{name: "permanent error", retryOn: []config.RetryOn{config.RetryOnTimeout, config.RetryOnNetwork, config.RetryOnServer}, err: errPermanent},
+		{name: "any", retryOn: []config.RetryOn{config.RetryOnAny}, err: errPermanent, want: true},
+		{name: "disruption budget", retryOn: []config.RetryOn{config.RetryOnServer}, err: actions.ErrDisruptionBudget},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := isRetryable(&config.Retry{RetryOn: tt.retryOn}, tt.err); got != tt.want {
+				t.Errorf("isRetryable(%v, %v) = %v, want %v", tt.retryOn, tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	t.Parallel()
+
+	retry := &config.Retry{
+		InitialBackoff: config.Duration(time.Second),
+		MaxBackoff:     config.Duration(10 * time.Second),
+	}
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 1, want: time.Second},
+		{attempt: 2, want: 2 * time.Second},
+		{attempt: 3, want: 4 * time.Second},
+		{attempt: 4, want: 8 * time.Second},
+		// Capped by the max backoff
+		{attempt: 5, want: 10 * time.Second},
+		{attempt: 1000, want: 10 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.attempt), func(t *testing.T) {
+			t.Parallel()
+
+			if got := backoff(retry, tt.attempt); got != tt.want {
+				t.Errorf("backoff(%d) = %s, want %s", tt.attempt, got, tt.want)
+			}
+
+			// Jitter randomizes the delay within the fraction of it
+			jittered := *retry
+			jittered.Jitter = 0.2
+			low := tt.want - tt.want/5
+			high := tt.want + tt.want/5
+			seen := make(map[time.Duration]bool)
+			for range 100 {
+				got := backoff(&jittered, tt.attempt)
+				if got < low || got > high {
+					t.Fatalf("jittered backoff(%d) = %s, want between %s and %s", tt.attempt, got, low, high)
+				}
+				seen[got] = true
+			}
+			if len(seen) < 2 {
+				t.Errorf("jittered backoff(%d) always returned %s", tt.attempt, tt.want)
+			}
+		})
+	}
+}
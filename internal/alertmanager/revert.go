@@ -0,0 +1,313 @@
+package alertmanager
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/USA-RedDragon/metrics-actioner/internal/history"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	// errNoRevert is returned for resolved alerts with nothing to revert yet
+	errNoRevert      = errors.New("no pending revert")
+	errNotReversible = errors.New("action isn't reversible")
+)
+
+// pendingRevert is what an action changes for a firing alert, restored
+// when the alert resolves
+type pendingRevert struct {
+	// executionID is the execution that changes the state
+	executionID string
+	action      string
+	options     map[string]string
+	// state is nil until the execution succeeds
+	state     map[string]string
+	expiresAt time.Time
+	// resolved is set when the alert resolved before the execution finished
+	resolved *resolvedAlert
+}
+
+// resolvedAlert is a resolved notification waiting for the execution it reverts
+type resolvedAlert struct {
+	data        *TemplateData
+	trigger     history.Trigger
+	spanContext trace.SpanContext
+}
+
+// reverter tracks the pending reverts of the rules with revert_on_resolve,
+// from when the action is queued until the alert resolves or the TTL
+// expires. They're restored from the history on startup.
+type reverter struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	pending map[string]*pendingRevert
+}
+
+func newReverter(ttl time.Duration) *reverter {
+	return &reverter{
+		ttl:     ttl,
+		pending: make(map[string]*pendingRevert),
+	}
+}
+
+func revertKey(ruleName, fingerprint string) string {
+	return ruleName + "\x00" + fingerprint
+}
+
+// expire forgets the pending reverts past their TTL. rv.mu must be held.
+func (rv *reverter) expire(now time.Time) {
+	for key, pending := range rv.pending {
+		if now.After(pending.expiresAt) {
+			slog.Warn("Forgetting pending revert past its TTL", "executionID", pending.executionID)
+			delete(rv.pending, key)
+		}
+	}
+}
+
+// track starts tracking the revert of a queued execution. An existing
+// revert is kept so that the original state is restored if the action
+// runs more than once.
+func (rv *reverter) track(key string, pending *pendingRevert, now time.Time) {
+	rv.mu.Lock()
+	defer rv.mu.Unlock()
+
+	rv.expire(now)
+	if _, ok := rv.pending[key]; ok {
+		return
+	}
+	pending.expiresAt = now.Add(rv.ttl)
+	rv.pending[key] = pending
+}
+
+// finish saves the state changed by the tracked execution, or forgets the
+// revert when nothing changed. Other executions are ignored. It returns
+// the revert if the alert already resolved and it should run now.
+func (rv *reverter) finish(key, executionID string, state map[string]string) *pendingRevert {
+	rv.mu.Lock()
+	defer rv.mu.Unlock()
+
+	pending, ok := rv.pending[key]
+	if !ok || pending.executionID != executionID {
+		return nil
+	}
+	if len(state) == 0 {
+		delete(rv.pending, key)
+		return nil
+	}
+	pending.state = maps.Clone(state)
+	if pending.resolved == nil {
+		return nil
+	}
+	delete(rv.pending, key)
+	return pending
+}
+
+// resolve returns the revert to run for a resolved alert, removing it.
+// If the execution is still queued or running, the resolved alert is kept
+// until it finishes and nil is returned.
+func (rv *reverter) resolve(key string, resolved *resolvedAlert, now time.Time) *pendingRevert {
+	rv.mu.Lock()
+	defer rv.mu.Unlock()
+
+	rv.expire(now)
+	pending, ok := rv.pending[key]
+	if !ok {
+		return nil
+	}
+	if pending.state == nil {
+		pending.resolved = resolved
+		return nil
+	}
+	delete(rv.pending, key)
+	return pending
+}
+
+// restore puts back a revert that could not be queued or failed
+func (rv *reverter) restore(key string, pending *pendingRevert) {
+	rv.mu.Lock()
+	defer rv.mu.Unlock()
+
+	if _, ok := rv.pending[key]; !ok {
+		rv.pending[key] = pending
+	}
+}
+
+// firingRevertKey returns the key of the revert of a rule's execution for
+// an alert, if the rule reverts it. Nothing changes in a dry run, so there's
+// nothing to revert.
+func firingRevertKey(alertRule *rule, data *TemplateData, dryRun bool) (string, bool) {
+	if !alertRule.cfg.RevertOnResolve || dryRun || data.Alert == nil || data.Alert.Fingerprint == "" {
+		return "", false
+	}
+	return revertKey(alertRule.cfg.Name, data.Alert.Fingerprint), true
+}
+
+// trackRevert tracks the revert of an execution about to be queued
+func (r *Receiver) trackRevert(alertRule *rule, data *TemplateData, execution *history.Execution, options map[string]string) {
+	key, ok := firingRevertKey(alertRule, data, execution.DryRun)
+	if !ok {
+		if alertRule.cfg.RevertOnResolve && !execution.DryRun {
+			slog.Warn("Alert has no fingerprint, its action won't be reverted", "id", execution.ID, "rule", alertRule.cfg.Name)
+		}
+		return
+	}
+	r.reverter.track(key, &pendingRevert{
+		executionID: execution.ID,
+		action:      alertRule.cfg.Action,
+		options:     options,
+	}, execution.CreatedAt)
+}
+
+// forgetRevert stops tracking the revert of an execution that didn't run
+func (r *Receiver) forgetRevert(alertRule *rule, data *TemplateData, execution *history.Execution) {
+	if key, ok := firingRevertKey(alertRule, data, execution.DryRun); ok {
+		r.reverter.finish(key, execution.ID, nil)
+	}
+}
+
+// finishRevert saves the state changed by a finished execution for its
+// revert, which is queued right away if the alert already resolved. A
+// failed execution passes a nil state, forgetting the revert.
+func (r *Receiver) finishRevert(j *job, state map[string]string) {
+	key, ok := firingRevertKey(j.rule, j.data, j.dryRun)
+	if !ok || j.revert != nil {
+		return
+	}
+	pending := r.reverter.finish(key, j.execution.ID, state)
+	if pending == nil {
+		return
+	}
+	resolved := pending.resolved
+	ctx := trace.ContextWithSpanContext(context.Background(), resolved.spanContext)
+	if _, err := r.queueRevert(ctx, j.rule, key, pending, resolved.data, resolved.trigger); err != nil && !errors.Is(err, errNoRevert) {
+		slog.Error("Failed to queue revert", "rule", j.rule.cfg.Name, "revertOf", pending.executionID, "error", err.Error())
+	}
+}
+
+// keepRevert puts back the revert of a job that didn't revert anything,
+// so a later resolved notification of the alert tries again
+func (r *Receiver) keepRevert(j *job) {
+	j.revert.resolved = nil
+	r.reverter.restore(revertKey(j.rule.cfg.Name, j.data.Alert.Fingerprint), j.revert)
+}
+
+// enqueueRevert queues the revert of what the rule's action changed when
+// the resolved alert fired, returning the recorded execution. Alerts
+// without a pending revert return errNoRevert, as do alerts whose
+// execution is still queued or running, which is reverted once it finishes.
+func (r *Receiver) enqueueRevert(ctx context.Context, alertRule *rule, data *TemplateData, trigger history.Trigger) (*history.Execution, error) {
+	key := revertKey(alertRule.cfg.Name, data.Alert.Fingerprint)
+	pending := r.reverter.resolve(key, &resolvedAlert{
+		data:        data,
+		trigger:     trigger,
+		spanContext: trace.SpanContextFromContext(ctx),
+	}, time.Now())
+	if pending == nil {
+		return nil, errNoRevert
+	}
+	return r.queueRevert(ctx, alertRule, key, pending, data, trigger)
+}
+
+func (r *Receiver) queueRevert(ctx context.Context, alertRule *rule, key string, pending *pendingRevert, data *TemplateData, trigger history.Trigger) (*history.Execution, error) {
+	if pending.action != alertRule.cfg.Action {
+		slog.Warn("Rule's action changed since it fired, not reverting", "rule", alertRule.cfg.Name, "action", alertRule.cfg.Action, "previousAction", pending.action, "revertOf", pending.executionID)
+		return nil, errNoRevert
+	}
+
+	now := time.Now()
+	execution := newExecution(alertRule, data, trigger, now)
+	execution.DryRun = alertRule.dryRun
+	execution.RevertOf = pending.executionID
+	execution.Options = pending.options
+	slog.Info("Reverting action", "rule", alertRule.cfg.Name, "action", alertRule.cfg.Action, "revertOf", pending.executionID)
+
+	// Record before queueing, as the execution belongs to the worker once queued
+	execution.Status = history.StatusQueued
+	r.record(execution)
+	queued := *execution
+	err := r.queue.enqueue(&job{
+		rule:        alertRule,
+		data:        data,
+		options:     pending.options,
+		dryRun:      execution.DryRun,
+		execution:   execution,
+		revert:      pending,
+		spanContext: trace.SpanContextFromContext(ctx),
+	})
+	if err != nil {
+		// Keep the revert for AlertManager's retry of the notification
+		pending.resolved = nil
+		r.reverter.restore(key, pending)
+		execution.Status = history.StatusFailed
+		execution.Error = err.Error()
+		r.record(execution)
+		return execution, err
+	}
+	return &queued, nil
+}
+
+// restoreReverts restores the pending reverts recorded in the history:
+// executions of rules with revert_on_resolve that saved a revert state
+// within the TTL and weren't reverted yet
+func (r *Receiver) restoreReverts() error {
+	revertRules := make(map[string]bool)
+	for _, alertRule := range r.rules.Load().rules {
+		if alertRule.cfg.RevertOnResolve {
+			revertRules[alertRule.cfg.Name] = true
+		}
+	}
+	if len(revertRules) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	filter := &history.Filter{Since: now.Add(-r.reverter.ttl), Limit: history.MaxListLimit}
+	reverted := make(map[string]bool)
+	var candidates []*history.Execution
+	for {
+		executions, cursor, err := r.history.List(filter)
+		if err != nil {
+			return err
+		}
+		for _, execution := range executions {
+			switch {
+			case execution.RevertOf != "":
+				// Reverts that failed or never ran, like ones dropped on shutdown, are still pending
+				if execution.Status == history.StatusSucceeded {
+					reverted[execution.RevertOf] = true
+				}
+			case revertRules[execution.Rule] && len(execution.RevertState) > 0 && len(execution.Fingerprints) == 1:
+				candidates = append(candidates, execution)
+			}
+		}
+		if cursor == "" {
+			break
+		}
+		filter.Cursor = cursor
+	}
+
+	r.reverter.mu.Lock()
+	defer r.reverter.mu.Unlock()
+	// Executions are listed newest first, so the oldest state of an alert wins
+	for _, execution := range candidates {
+		if reverted[execution.ID] {
+			continue
+		}
+		r.reverter.pending[revertKey(execution.Rule, execution.Fingerprints[0])] = &pendingRevert{
+			executionID: execution.ID,
+			action:      execution.Action,
+			options:     execution.Options,
+			state:       execution.RevertState,
+			expiresAt:   execution.CreatedAt.Add(r.reverter.ttl),
+		}
+	}
+	if len(r.reverter.pending) > 0 {
+		slog.Info("Restored pending reverts from the history", "count", len(r.reverter.pending))
+	}
+	return nil
+}
@@ -0,0 +1,429 @@
+package alertmanager
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/USA-RedDragon/metrics-actioner/internal/alertmanager/actions"
+	"github.com/USA-RedDragon/metrics-actioner/internal/alertmanager/models"
+	"github.com/USA-RedDragon/metrics-actioner/internal/config"
+	"github.com/USA-RedDragon/metrics-actioner/internal/history"
+)
+
+var errRevertFailed = errors.New("revert failed")
+
+// fakeReversible saves the replicas it scaled from and records its reverts
+type fakeReversible struct {
+	mu        sync.Mutex
+	revertErr error
+	reverts   []map[string]string
+}
+
+func (a *fakeReversible) Execute(_ context.Context, req *actions.Request) error {
+	req.State["replicas"] = "3"
+	return nil
+}
+
+func (a *fakeReversible) OptionSchema() actions.Schema {
+	return actions.Schema{}
+}
+
+func (a *fakeReversible) Revert(_ context.Context, req *actions.Request) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.reverts = append(a.reverts, maps.Clone(req.State))
+	return a.revertErr
+}
+
+func TestReverter(t *testing.T) {
+	t.Parallel()
+
+	type step struct {
+		op          string
+		executionID string
+		state       map[string]string
+		after       time.Duration
+		// want is the execution of the revert returned by finish or resolve
+		want string
+	}
+	state := map[string]string{"replicas": "3"}
+	tests := []struct {
+		name  string
+		steps []step
+		// pending is the execution of the revert left pending
+		pending string
+	}{
+		{
+			name: "resolved after the execution finished",
+			steps: []step{
+				{op: "track", executionID: "e1"},
+				{op: "finish", executionID: "e1", state: state},
+				{op: "resolve", want: "e1"},
+			},
+		},
+		{
+			name: "resolved while still queued",
+			steps: []step{
+				{op: "track", executionID: "e1"},
+				{op: "resolve"},
+				{op: "finish", executionID: "e1", state: state, want: "e1"},
+			},
+		},
+		{
+			name: "nothing changed",
+			steps: []step{
+				{op: "track", executionID: "e1"},
+				{op: "finish", executionID: "e1"},
+				{op: "resolve"},
+			},
+		},
+		{
+			name: "resolved while queued and nothing changed",
+			steps: []step{
+				{op: "track", executionID: "e1"},
+				{op: "resolve"},
+				{op: "finish", executionID: "e1"},
+			},
+		},
+		{
+			name: "not tracked",
+			steps: []step{
+				{op: "resolve"},
+			},
+		},
+		{
+			// The first execution saw the original state
+			name: "later executions ignored",
+			steps: []step{
+				{op: "track", executionID: "e1"},
+				{op: "finish", executionID: "e1", state: state},
+				{op: "track", executionID: "e2", after: time.Minute},
+				{op: "finish", executionID: "e2", state: map[string]string{"replicas": "6"}, after: time.Minute},
+			},
+			pending: "e1",
+		},
+		{
+			name: "expired",
+			steps: []step{
+				{op: "track", executionID: "e1"},
+				{op: "finish", executionID: "e1", state: state},
+				{op: "resolve", after: 2 * time.Hour},
+			},
+		},
+		{
+			name: "restored after a failed revert",
+			steps: []step{
+				{op: "track", executionID: "e1"},
+				{op: "finish", executionID: "e1", state: state},
+				{op: "resolve", want: "e1"},
+				{op: "restore", executionID: "e1"},
+				{op: "resolve", after: time.Minute, want: "e1"},
+			},
+		},
+		{
+			name: "restore keeps a newer revert",
+			steps: []step{
+				{op: "track", executionID: "e1"},
+				{op: "finish", executionID: "e1", state: state},
+				{op: "resolve", want: "e1"},
+				{op: "track", executionID: "e2", after: time.Minute},
+				{op: "restore", executionID: "e1"},
+			},
+			pending: "e2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rv := newReverter(time.Hour)
+			key := revertKey("r", "f")
+			start := time.Now()
+			returned := make(map[string]*pendingRevert)
+			for i, s := range tt.steps {
+				var got *pendingRevert
+				switch s.op {
+				case "track":
+					rv.track(key, &pendingRevert{executionID: s.executionID}, start.Add(s.after))
+				case "finish":
+					got = rv.finish(key, s.executionID, s.state)
+				case "resolve":
+					got = rv.resolve(key, &resolvedAlert{}, start.Add(s.after))
+				case "restore":
+					rv.restore(key, returned[s.executionID])
+				}
+				var gotID string
+				if got != nil {
+					gotID = got.executionID
+					returned[gotID] = got
+					if !maps.Equal(got.state, state) {
+						t.Errorf("step %d: %s returned the state %v, want %v", i, s.op, got.state, state)
+					}
+				}
+				if gotID != s.want {
+					t.Errorf("step %d: %s returned the revert of %q, want %q", i, s.op, gotID, s.want)
+				}
+			}
+
+			var pending string
+			if p, ok := rv.pending[key]; ok {
+				pending = p.executionID
+			}
+			if pending != tt.pending {
+				t.Errorf("pending revert of %q, want %q", pending, tt.pending)
+			}
+		})
+	}
+}
+
+func newRevertTestReceiver(t *testing.T, action ActionIface) (*Receiver, *rule) {
+	t.Helper()
+
+	r := &Receiver{
+		history:      history.NewMemoryStore(0),
+		limiter:      newLimiter(),
+		deduplicator: newDeduplicator(time.Hour),
+		reverter:     newReverter(time.Hour),
+	}
+	alertRule := &rule{
+		cfg: config.Action{
+			Name:            "r",
+			Action:          "fake",
+			RevertOnResolve: true,
+			Timeout:         config.Duration(time.Minute),
+			Retry:           config.Retry{MaxAttempts: 1},
+		},
+		action: action,
+	}
+	r.rules.Store(&ruleSet{rules: []*rule{alertRule}})
+	r.queue = newQueue(&config.Workers{Concurrency: 1, QueueSize: 10}, r.execute, r.dropped)
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		r.queue.stop(ctx)
+	})
+	return r, alertRule
+}
+
+func alertData(status models.AlertStatus) *TemplateData {
+	webhook := &models.Webhook{
+		GroupKey: "g",
+		Status:   string(status),
+		Alerts:   []models.Alert{{Fingerprint: "f", Status: status, StartsAt: time.Now()}},
+	}
+	return newAlertTemplateData(webhook, &webhook.Alerts[0])
+}
+
+// waitForRevert waits for the revert of an execution to finish
+func waitForRevert(t *testing.T, store history.Store, revertOf string) *history.Execution {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		executions, _, err := store.List(&history.Filter{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, execution := range executions {
+			if execution.RevertOf == revertOf && (execution.Status == history.StatusSucceeded || execution.Status == history.StatusFailed) {
+				return execution
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("revert of %s didn't finish", revertOf)
+	return nil
+}
+
+func TestReceiverRevert(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		// resolveQueued resolves the alert before the action runs
+		resolveQueued bool
+		revertErr     error
+		want          history.Status
+	}{
+		{name: "resolved after the action ran", want: history.StatusSucceeded},
+		{name: "resolved while still queued", resolveQueued: true, want: history.StatusSucceeded},
+		{name: "failed revert", revertErr: errRevertFailed, want: history.StatusFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			action := &fakeReversible{revertErr: tt.revertErr}
+			r, alertRule := newRevertTestReceiver(t, action)
+			ctx := context.Background()
+
+			execution, err := r.enqueue(ctx, alertRule, alertData(models.AlertStatusFiring), history.TriggerWebhook)
+			if err != nil {
+				t.Fatalf("enqueue: %v", err)
+			}
+			if tt.resolveQueued {
+				// The revert waits for the action, which hasn't run yet
+				if _, err := r.enqueueRevert(ctx, alertRule, alertData(models.AlertStatusResolved), history.TriggerWebhook); !errors.Is(err, errNoRevert) {
+					t.Fatalf("enqueueRevert while queued error = %v, want %v", err, errNoRevert)
+				}
+				r.queue.start()
+			} else {
+				r.queue.start()
+				deadline := time.Now().Add(5 * time.Second)
+				for {
+					saved, err := r.history.Get(execution.ID)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if saved.Status == history.StatusSucceeded {
+						break
+					}
+					if time.Now().After(deadline) {
+						t.Fatalf("action didn't succeed, status %s", saved.Status)
+					}
+					time.Sleep(10 * time.Millisecond)
+				}
+				if _, err := r.enqueueRevert(ctx, alertRule, alertData(models.AlertStatusResolved), history.TriggerWebhook); err != nil {
+					t.Fatalf("enqueueRevert: %v", err)
+				}
+			}
+
+			revert := waitForRevert(t, r.history, execution.ID)
+			if revert.Status != tt.want {
+				t.Errorf("revert status = %s, want %s", revert.Status, tt.want)
+			}
+			action.mu.Lock()
+			reverts := slices.Clone(action.reverts)
+			action.mu.Unlock()
+			if len(reverts) != 1 || !maps.Equal(reverts[0], map[string]string{"replicas": "3"}) {
+				t.Errorf("reverted with %v, want the saved state once", reverts)
+			}
+
+			// A failed revert is tried again on the next resolved notification
+			r.reverter.mu.Lock()
+			_, ok := r.reverter.pending[revertKey("r", "f")]
+			r.reverter.mu.Unlock()
+			if want := tt.revertErr != nil; ok != want {
+				t.Errorf("revert still pending = %v, want %v", ok, want)
+			}
+		})
+	}
+}
+
+func TestRestoreReverts(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	state := map[string]string{"replicas": "3"}
+	execution := func(rule string, ago time.Duration, status history.Status, revertState map[string]string) *history.Execution {
+		createdAt := now.Add(-ago)
+		return &history.Execution{
+			ID:           history.NewID(createdAt),
+			Rule:         rule,
+			Action:       "fake",
+			Fingerprints: []string{"f"},
+			Status:       status,
+			CreatedAt:    createdAt,
+			RevertState:  revertState,
+		}
+	}
+	revertOf := func(original *history.Execution, status history.Status) *history.Execution {
+		revert := execution(original.Rule, 0, status, nil)
+		revert.RevertOf = original.ID
+		return revert
+	}
+
+	tests := []struct {
+		name       string
+		executions func() ([]*history.Execution, string)
+	}{
+		{
+			name: "not reverted",
+			executions: func() ([]*history.Execution, string) {
+				e := execution("r", time.Minute, history.StatusSucceeded, state)
+				return []*history.Execution{e}, e.ID
+			},
+		},
+		{
+			name: "reverted",
+			executions: func() ([]*history.Execution, string) {
+				e := execution("r", time.Minute, history.StatusSucceeded, state)
+				return []*history.Execution{e, revertOf(e, history.StatusSucceeded)}, ""
+			},
+		},
+		{
+			name: "revert failed",
+			executions: func() ([]*history.Execution, string) {
+				e := execution("r", time.Minute, history.StatusSucceeded, state)
+				return []*history.Execution{e, revertOf(e, history.StatusFailed)}, e.ID
+			},
+		},
+		{
+			name: "revert dropped on shutdown",
+			executions: func() ([]*history.Execution, string) {
+				e := execution("r", time.Minute, history.StatusSucceeded, state)
+				return []*history.Execution{e, revertOf(e, history.StatusCancelled)}, e.ID
+			},
+		},
+		{
+			name: "nothing changed",
+			executions: func() ([]*history.Execution, string) {
+				return []*history.Execution{execution("r", time.Minute, history.StatusSucceeded, nil)}, ""
+			},
+		},
+		{
+			name: "rule without revert_on_resolve",
+			executions: func() ([]*history.Execution, string) {
+				return []*history.Execution{execution("other", time.Minute, history.StatusSucceeded, state)}, ""
+			},
+		},
+		{
+			name: "past the TTL",
+			executions: func() ([]*history.Execution, string) {
+				return []*history.Execution{execution("r", 2*time.Hour, history.StatusSucceeded, state)}, ""
+			},
+		},
+		{
+			name: "oldest state wins",
+			executions: func() ([]*history.Execution, string) {
+				older := execution("r", 2*time.Minute, history.StatusSucceeded, state)
+				newer := execution("r", time.Minute, history.StatusSucceeded, map[string]string{"replicas": "6"})
+				return []*history.Execution{older, newer}, older.ID
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			r, _ := newRevertTestReceiver(t, &fakeReversible{})
+			r.rules.Load().rules = append(r.rules.Load().rules, &rule{cfg: config.Action{Name: "other", Action: "fake"}})
+			executions, want := tt.executions()
+			for _, e := range executions {
+				if err := r.history.Save(e); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := r.restoreReverts(); err != nil {
+				t.Fatalf("restoreReverts: %v", err)
+			}
+			var got string
+			pending, ok := r.reverter.pending[revertKey("r", "f")]
+			if ok {
+				got = pending.executionID
+				if !maps.Equal(pending.state, state) {
+					t.Errorf("restored the state %v, want %v", pending.state, state)
+				}
+			}
+			if got != want {
+				t.Errorf("restored the revert of %q, want %q", got, want)
+			}
+		})
+	}
+}
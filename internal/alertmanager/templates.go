@@ -0,0 +1,130 @@
+package alertmanager
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/USA-RedDragon/metrics-actioner/internal/alertmanager/models"
+	"github.com/USA-RedDragon/metrics-actioner/internal/config"
+)
+
+// TemplateData is the data action options are rendered against.
+// In the group match mode the labels and annotations are the webhook's
+// common ones, in the alert match mode they are the matched alert's.
+type TemplateData struct {
+	Status            string
+	Labels            models.Labels
+	Annotations       models.Annotations
+	Fingerprint       string
+	GroupLabels       models.Labels
+	CommonLabels      models.Labels
+	CommonAnnotations models.Annotations
+	ExternalURL       string
+	Webhook           *models.Webhook
+	Alert             *models.Alert
+}
+
+func newGroupTemplateData(webhook *models.Webhook) *TemplateData {
+	return &TemplateData{
+		Status:            webhook.Status,
+		Labels:            webhook.CommonLabels,
+		Annotations:       webhook.CommonAnnotations,
+		GroupLabels:       webhook.GroupLabels,
+		CommonLabels:      webhook.CommonLabels,
+		CommonAnnotations: webhook.CommonAnnotations,
+		ExternalURL:       webhook.ExternalURL,
+		Webhook:           webhook,
+	}
+}
+
+func newAlertTemplateData(webhook *models.Webhook, alert *models.Alert) *TemplateData {
+	data := newGroupTemplateData(webhook)
+	data.Status = string(alert.Status)
+	data.Labels = alert.Labels
+	data.Annotations = alert.Annotations
+	data.Fingerprint = alert.Fingerprint
+	data.Alert = alert
+	return data
+}
+
+type hostPort struct {
+	Host string
+	Port string
+}
+
+func templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		// default returns def if val is empty, e.g. {{ .Labels.namespace | default "default" }}
+		"default": func(def, val string) string {
+			if val == "" {
+				return def
+			}
+			return val
+		},
+		"lower":      strings.ToLower,
+		"upper":      strings.ToUpper,
+		"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+		"regexReplace": func(pattern, replacement, s string) (string, error) {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return "", err
+			}
+			return re.ReplaceAllString(s, replacement), nil
+		},
+		// shellQuote quotes a value as a single shell word, e.g.
+		// systemctl restart {{ .Labels.service | shellQuote }}
+		"shellQuote": shellQuote,
+		// splitHostPort splits an `instance` label, e.g. {{ (splitHostPort .Labels.instance).Host }}
+		// If there is no port, the whole value is returned as the host.
+		"splitHostPort": func(s string) hostPort {
+			host, port, err := net.SplitHostPort(s)
+			if err != nil {
+				return hostPort{Host: s}
+			}
+			return hostPort{Host: host, Port: port}
+		},
+	}
+}
+
+// shellQuote quotes s in single quotes for a POSIX shell, so that labels
+// can't inject commands into an ssh action's command
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
+
+type optionTemplates map[string]*template.Template
+
+func parseOptionTemplates(options config.Options) (optionTemplates, error) {
+	templates := make(optionTemplates, len(options))
+	for key, value := range options {
+		tmpl, err := parseOptionTemplate(key, value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse template for option %s: %w", key, err)
+		}
+		templates[key] = tmpl
+	}
+	return templates, nil
+}
+
+func parseOptionTemplate(key, value string) (*template.Template, error) {
+	return template.New(key).
+		Option("missingkey=zero").
+		Funcs(templateFuncs()).
+		Parse(value)
+}
+
+func (t optionTemplates) render(data *TemplateData) (map[string]string, error) {
+	rendered := make(map[string]string, len(t))
+	for key, tmpl := range t {
+		var sb strings.Builder
+		if err := tmpl.Execute(&sb, data); err != nil {
+			return nil, fmt.Errorf("failed to render option %s: %w", key, err)
+		}
+		rendered[key] = sb.String()
+	}
+	return rendered, nil
+}
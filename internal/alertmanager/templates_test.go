@@ -0,0 +1,41 @@
+package alertmanager
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestShellQuote(t *testing.T) {
+	t.Parallel()
+
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no shell to run the quoted values")
+	}
+	tests := []string{
+		"nginx",
+		"",
+		"two words",
+		"it's",
+		"'",
+		"a; rm -rf /",
+		"$(reboot)",
+		"`reboot`",
+		`"$HOME"`,
+		"line\nbreak",
+	}
+	for _, value := range tests {
+		t.Run(value, func(t *testing.T) {
+			t.Parallel()
+
+			// The shell sees the quoted value as one word, unchanged
+			out, err := exec.Command(sh, "-c", "printf %s "+shellQuote(value)).Output()
+			if err != nil {
+				t.Fatalf("running the quoted value: %v", err)
+			}
+			if string(out) != value {
+				t.Errorf("shell got %q, want %q", out, value)
+			}
+		})
+	}
+}
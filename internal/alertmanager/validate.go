@@ -0,0 +1,55 @@
+package alertmanager
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/USA-RedDragon/metrics-actioner/internal/config"
+)
+
+// ValidateConfig checks the rules against the registered actions: every
+// action must exist, its options must fit the action's schema, the option
+// templates must parse and rules with revert_on_resolve must use a
+// reversible action. Problems are reported with the path of the offending
+// field.
+func ValidateConfig(cfg *config.Config) error {
+	registeredActions := findActions()
+	names := make([]string, 0, len(registeredActions))
+	for name := range registeredActions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for action := range cfg.Workers.ActionConcurrency {
+		if _, ok := registeredActions[action]; !ok {
+			errs = append(errs, fmt.Errorf("workers.action_concurrency.%s: unknown action, must be one of %s", action, strings.Join(names, ", ")))
+		}
+	}
+	for i, action := range cfg.HTTP.ManualRuns.AllowedActions {
+		if _, ok := registeredActions[action]; !ok {
+			errs = append(errs, fmt.Errorf("http.manual_runs.allowed_actions[%d]: unknown action %q, must be one of %s", i, action, strings.Join(names, ", ")))
+		}
+	}
+	for i, actionConfig := range cfg.Actions {
+		action, ok := registeredActions[actionConfig.Action]
+		if !ok {
+			errs = append(errs, fmt.Errorf("actions[%d].action: unknown action %q, must be one of %s", i, actionConfig.Action, strings.Join(names, ", ")))
+		} else {
+			if err := action.OptionSchema().Validate(fmt.Sprintf("actions[%d].options", i), actionConfig.Options); err != nil {
+				errs = append(errs, err)
+			}
+			if _, reversible := action.(ReversibleActionIface); actionConfig.RevertOnResolve && !reversible {
+				errs = append(errs, fmt.Errorf("actions[%d].revert_on_resolve: action %q can't be reverted", i, actionConfig.Action))
+			}
+		}
+		for key, value := range actionConfig.Options {
+			if _, err := parseOptionTemplate(key, value); err != nil {
+				errs = append(errs, fmt.Errorf("actions[%d].options.%s: %w", i, key, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
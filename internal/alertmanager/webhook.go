@@ -1,70 +1,261 @@
 package alertmanager
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
+	"sync/atomic"
+	"time"
 
 	"github.com/USA-RedDragon/metrics-actioner/internal/alertmanager/models"
 	"github.com/USA-RedDragon/metrics-actioner/internal/config"
+	"github.com/USA-RedDragon/metrics-actioner/internal/history"
+	"github.com/USA-RedDragon/metrics-actioner/internal/metrics"
+	"github.com/USA-RedDragon/metrics-actioner/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Receiver struct {
-	config            *[]config.Action
+	// rules are swapped as a whole when the config is reloaded
+	rules             atomic.Pointer[ruleSet]
 	registeredActions map[string]ActionIface
+	queue             *queue
+	limiter           *limiter
+	deduplicator      *deduplicator
+	reverter          *reverter
+	// manualRuns is only read on startup
+	manualRuns config.ManualRuns
+	history    history.Store
 }
 
-func NewReceiver(config *[]config.Action) *Receiver {
-	return &Receiver{
-		config:            config,
+// ruleSet is the rules of a config
+type ruleSet struct {
+	rules []*rule
+	// dryRun is the global dry_run setting
+	dryRun bool
+}
+
+// rule is an action from the config with its option templates parsed
+type rule struct {
+	cfg     config.Action
+	action  ActionIface
+	options optionTemplates
+	// dryRun is set by the rule or the global dry_run setting
+	dryRun bool
+}
+
+func NewReceiver(config *config.Config, history history.Store) (*Receiver, error) {
+	r := &Receiver{
+		history:           history,
 		registeredActions: findActions(),
+		limiter:           newLimiter(),
+		deduplicator:      newDeduplicator(time.Duration(config.Deduplication.TTL)),
+		reverter:          newReverter(time.Duration(config.Deduplication.TTL)),
+		manualRuns:        config.HTTP.ManualRuns,
+	}
+	if err := r.Reload(config); err != nil {
+		return nil, err
+	}
+	if err := r.restoreReverts(); err != nil {
+		return nil, fmt.Errorf("failed to restore pending reverts: %w", err)
+	}
+	r.queue = newQueue(&config.Workers, r.execute, r.dropped)
+	return r, nil
+}
+
+// Reload validates the config's rules and atomically replaces the current
+// ones. The current rules are kept if the config is invalid.
+// Webhooks being matched and queued actions finish with the rules they started with.
+func (r *Receiver) Reload(config *config.Config) error {
+	if err := ValidateConfig(config); err != nil {
+		return err
+	}
+	rules := make([]*rule, 0, len(config.Actions))
+	for i, actionConfig := range config.Actions {
+		rule, err := r.newRule(actionConfig, config.DryRun)
+		if err != nil {
+			return fmt.Errorf("actions[%d]: %w", i, err)
+		}
+		rules = append(rules, rule)
+	}
+	r.rules.Store(&ruleSet{rules: rules, dryRun: config.DryRun})
+	return nil
+}
+
+func (r *Receiver) newRule(actionConfig config.Action, dryRun bool) (*rule, error) {
+	action, err := r.FindAction(actionConfig.Action)
+	if err != nil {
+		return nil, err
 	}
+	options, err := parseOptionTemplates(actionConfig.Options)
+	if err != nil {
+		return nil, err
+	}
+	return &rule{
+		cfg:     actionConfig,
+		action:  action,
+		options: options,
+		dryRun:  dryRun || actionConfig.DryRun,
+	}, nil
+}
+
+// Start starts the workers executing matched actions
+func (r *Receiver) Start() {
+	r.queue.start()
+}
+
+// Stop stops accepting webhooks and waits for queued actions to finish,
+// cancelling the in-flight actions once ctx is done
+func (r *Receiver) Stop(ctx context.Context) {
+	r.queue.stop(ctx)
 }
 
-func matchLabels(webhookLabels models.Labels, ruleLabels config.Labels) bool {
-	for key, value := range ruleLabels {
-		if webhookLabels[key] != value {
-			return false
+// labelsMismatch returns the first rule label, in name order, that the
+// webhook's labels don't have, or an empty string if they all match
+func labelsMismatch(webhookLabels models.Labels, ruleLabels config.Labels) string {
+	for _, key := range slices.Sorted(maps.Keys(ruleLabels)) {
+		if webhookLabels[key] != ruleLabels[key] {
+			return fmt.Sprintf("%s=%q, got %q", key, ruleLabels[key], webhookLabels[key])
 		}
 	}
-	return true
+	return ""
 }
 
-func (r *Receiver) ReceiveWebhook(webhook *models.Webhook) error {
+// ReceiveWebhook matches the webhook against the rules and queues the
+// matching actions for execution
+func (r *Receiver) ReceiveWebhook(ctx context.Context, webhook *models.Webhook) error {
+	ctx, span := tracing.Tracer("alertmanager").Start(ctx, "ReceiveWebhook", trace.WithAttributes(
+		attribute.String("alertmanager.receiver", webhook.Receiver),
+		attribute.String("alertmanager.status", webhook.Status),
+		attribute.String("alertmanager.group_key", webhook.GroupKey),
+		attribute.String("alertmanager.alertname", webhook.CommonLabels["alertname"]),
+		attribute.Int("alertmanager.alerts", len(webhook.Alerts)),
+	))
+	defer span.End()
+
 	// Print the json to the console
 	slog.Info("Received AlertManager webhook")
+	metrics.WebhooksReceived.WithLabelValues(webhook.Receiver, webhook.Status).Inc()
 
 	// For each defined action in the config
-	for _, alertRule := range *r.config {
-		if len(alertRule.MatchCommonLabels) > 0 {
-			// Check if the common labels match
-			if !matchLabels(webhook.CommonLabels, alertRule.MatchCommonLabels) {
-				// If the common labels don't match, skip this action
+	for _, alertRule := range r.rules.Load().rules {
+		if _, err := r.matchRule(ctx, alertRule, webhook, history.TriggerWebhook); err != nil {
+			tracing.RecordError(span, err)
+			return err
+		}
+	}
+	for _, alert := range webhook.Alerts {
+		slog.Info("Received AlertManager alert", "alert", alert)
+	}
+	return nil
+}
+
+// matchRule matches a rule against the webhook and queues its action for
+// each match, returning the recorded executions
+func (r *Receiver) matchRule(ctx context.Context, alertRule *rule, webhook *models.Webhook, trigger history.Trigger) ([]*history.Execution, error) {
+	ctx, span := tracing.Tracer("alertmanager").Start(ctx, "MatchRule", trace.WithAttributes(
+		attribute.String("rule", alertRule.cfg.Name),
+		attribute.String("action", alertRule.cfg.Action),
+	))
+	defer span.End()
+
+	matches := 0
+	var executions []*history.Execution
+	defer func() {
+		span.SetAttributes(attribute.Int("rule.matches", matches))
+	}()
+
+	ruleMatches, _ := matchWebhook(alertRule, webhook)
+	for _, match := range ruleMatches {
+		var execution *history.Execution
+		var err error
+		if match.revert {
+			// The resolved alert reverts what the action did when it fired, if anything
+			execution, err = r.enqueueRevert(ctx, alertRule, match.data, trigger)
+			if errors.Is(err, errNoRevert) {
 				continue
 			}
-		}
-		if len(alertRule.MatchGroupLabels) > 0 {
-			// Check if the group labels match
-			if !matchLabels(webhook.GroupLabels, alertRule.MatchGroupLabels) {
-				// If the group labels don't match, skip this action
+		} else {
+			matches++
+			metrics.RulesMatched.WithLabelValues(alertRule.cfg.Name, alertRule.cfg.Action).Inc()
+			if match.data.Alert != nil {
+				slog.Info("Matched alert rule with alert", "rule", alertRule.cfg.Name, "alert", match.data.Alert)
+			} else {
+				slog.Info("Matched alert rule with webhook", "rule", alertRule.cfg.Name, "webhook", webhook)
+			}
+			execution, err = r.enqueue(ctx, alertRule, match.data, trigger)
+			if errors.Is(err, ErrDuplicate) {
 				continue
 			}
 		}
-		// If the alert is not firing, skip this action
-		if webhook.Status != string(models.AlertStatusFiring) {
-			continue
+		if execution != nil {
+			executions = append(executions, execution)
 		}
-		slog.Info("Matched alert rule with webhook", "rule", alertRule, "webhook", webhook)
-		// We match so far, so we execute the action
-		action, err := r.FindAction(alertRule.Action)
 		if err != nil {
-			return err
+			tracing.RecordError(span, err)
+			return executions, err
 		}
-		err = action.Execute(webhook, alertRule.Options)
-		if err != nil {
-			return err
+	}
+	return executions, nil
+}
+
+// ruleMatch is a group or alert matched by a rule
+type ruleMatch struct {
+	data *TemplateData
+	// revert is set for resolved alerts reverting what the action did when they fired
+	revert bool
+}
+
+// matchWebhook matches a rule against the webhook's group or alerts,
+// depending on its match mode, returning what it matches and doesn't
+func matchWebhook(alertRule *rule, webhook *models.Webhook) ([]ruleMatch, []Mismatch) {
+	var matches []ruleMatch
+	var mismatches []Mismatch
+	switch alertRule.cfg.MatchMode {
+	case config.MatchModeAlert:
+		// Evaluate the rule against each alert, executing the action once per matching alert
+		for i := range webhook.Alerts {
+			alert := &webhook.Alerts[i]
+			if alertRule.cfg.RevertOnResolve && alert.Status == models.AlertStatusResolved && alert.Fingerprint != "" {
+				matches = append(matches, ruleMatch{data: newAlertTemplateData(webhook, alert), revert: true})
+				continue
+			}
+			if reason := mismatch(alertRule, webhook.GroupLabels, string(alert.Status), alert.Labels); reason != "" {
+				mismatches = append(mismatches, Mismatch{Alert: alert, Reason: reason})
+				continue
+			}
+			matches = append(matches, ruleMatch{data: newAlertTemplateData(webhook, alert)})
+		}
+	case config.MatchModeGroup:
+		if reason := mismatch(alertRule, webhook.GroupLabels, webhook.Status, webhook.CommonLabels); reason != "" {
+			mismatches = append(mismatches, Mismatch{Reason: reason})
+			break
 		}
+		matches = append(matches, ruleMatch{data: newGroupTemplateData(webhook)})
 	}
-	for _, alert := range webhook.Alerts {
-		slog.Info("Received AlertManager alert", "alert", alert)
+	return matches, mismatches
+}
+
+// mismatch returns why the rule doesn't match a webhook or alert with the
+// given status and labels, which are either the webhook's common labels or
+// a single alert's labels. It returns an empty string if the rule matches.
+func mismatch(alertRule *rule, groupLabels models.Labels, status string, labels models.Labels) string {
+	if reason := labelsMismatch(groupLabels, alertRule.cfg.MatchGroupLabels); reason != "" {
+		return "match_group_labels: " + reason
 	}
-	return nil
+	// If the status doesn't trigger this action, skip it
+	if !alertRule.cfg.On.Matches(status) {
+		return fmt.Sprintf("on: %s doesn't trigger on status %q", alertRule.cfg.On, status)
+	}
+	if reason := labelsMismatch(labels, alertRule.cfg.MatchCommonLabels); reason != "" {
+		return "match_common_labels: " + reason
+	}
+	if m := alertRule.cfg.Matchers.Mismatch(labels); m != nil {
+		return fmt.Sprintf("matchers: %s, got %q", m, labels[m.Name])
+	}
+	return ""
 }
@@ -1,10 +1,17 @@
 package config
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/ghodss/yaml"
 	"github.com/spf13/cobra"
@@ -17,41 +24,356 @@ type HTTPListener struct {
 	Port     uint16 `json:"port"`
 }
 
+type TracingProtocol string
+
+const (
+	TracingProtocolGRPC TracingProtocol = "grpc"
+	TracingProtocolHTTP TracingProtocol = "http"
+)
+
 type Tracing struct {
-	Enabled      bool   `json:"enabled"`
-	OTLPEndpoint string `json:"otlp_endpoint"`
+	Enabled      bool            `json:"enabled"`
+	OTLPEndpoint string          `json:"otlp_endpoint"`
+	OTLPProtocol TracingProtocol `json:"otlp_protocol"`
+	// OTLPInsecure disables TLS when exporting spans
+	OTLPInsecure bool `json:"otlp_insecure"`
 }
 
 type PProf struct {
 	Enabled bool `json:"enabled"`
 }
 
+// TLS serves a listener over TLS when a certificate is set.
+// The files are reloaded when they change.
+type TLS struct {
+	CertFile string `json:"cert_file"`
+	KeyFile  string `json:"key_file"`
+	// ClientCAFile enables mutual TLS, requiring client certificates signed
+	// by one of the CAs in the bundle. On the API listener, the webhook and
+	// executions endpoints require one, on the metrics listener every request does.
+	ClientCAFile string `json:"client_ca_file"`
+}
+
+// Enabled returns whether the listener is served over TLS
+func (t *TLS) Enabled() bool {
+	return t.CertFile != ""
+}
+
+// validate checks the TLS settings, prefixing problems with path
+func (t *TLS) validate(path string) []error {
+	var errs []error
+	if t.CertFile == "" && t.KeyFile == "" {
+		if t.ClientCAFile != "" {
+			errs = append(errs, fmt.Errorf("%s.client_ca_file: needs cert_file and key_file to be set", path))
+		}
+		return errs
+	}
+	if t.CertFile == "" || t.KeyFile == "" {
+		return append(errs, fmt.Errorf("%s: cert_file and key_file must be set together", path))
+	}
+	return errs
+}
+
+// checkFiles checks the TLS files can be loaded, prefixing problems with path
+func (t *TLS) checkFiles(path string) []error {
+	var errs []error
+	if !t.Enabled() || t.KeyFile == "" {
+		return errs
+	}
+	if _, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile); err != nil {
+		errs = append(errs, fmt.Errorf("%s: failed to load certificate: %w", path, err))
+	}
+	if t.ClientCAFile != "" {
+		if _, err := LoadCertPool(t.ClientCAFile); err != nil {
+			errs = append(errs, fmt.Errorf("%s.client_ca_file: %w", path, err))
+		}
+	}
+	return errs
+}
+
+// LoadCertPool reads a bundle of PEM encoded certificates
+func LoadCertPool(file string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no PEM encoded certificates found in %s", file)
+	}
+	return pool, nil
+}
+
 type Metrics struct {
 	HTTPListener
 	Enabled bool `json:"enabled"`
+	TLS     TLS  `json:"tls"`
+}
+
+type BasicAuth struct {
+	Username     string `json:"username"`
+	Password     string `json:"password"`
+	PasswordFile string `json:"password_file"`
+}
+
+type HMAC struct {
+	Secret     string `json:"secret"`
+	SecretFile string `json:"secret_file"`
+	// Header holds the hex encoded HMAC-SHA256 of the request body,
+	// optionally prefixed with "sha256="
+	Header string `json:"header"`
+}
+
+// Auth configures the authentication of the webhook endpoint. When several
+// methods are configured, a request passing any of them is accepted.
+// Secrets in files are read on every request so they can be rotated.
+type Auth struct {
+	BearerToken     string    `json:"bearer_token"`
+	BearerTokenFile string    `json:"bearer_token_file"`
+	BasicAuth       BasicAuth `json:"basic_auth"`
+	HMAC            HMAC      `json:"hmac"`
+}
+
+// Enabled returns whether any authentication method is configured
+func (a *Auth) Enabled() bool {
+	return a.BearerToken != "" || a.BearerTokenFile != "" ||
+		a.BasicAuth.Username != "" ||
+		a.HMAC.Secret != "" || a.HMAC.SecretFile != ""
+}
+
+// validate checks the authentication methods, prefixing problems with path
+func (a *Auth) validate(path string) []error {
+	var errs []error
+	if a.BearerToken != "" && a.BearerTokenFile != "" {
+		errs = append(errs, fmt.Errorf("%s: only one of bearer_token and bearer_token_file may be set", path))
+	}
+	if a.BasicAuth.Password != "" && a.BasicAuth.PasswordFile != "" {
+		errs = append(errs, fmt.Errorf("%s.basic_auth: only one of password and password_file may be set", path))
+	}
+	if a.BasicAuth.Username == "" && (a.BasicAuth.Password != "" || a.BasicAuth.PasswordFile != "") {
+		errs = append(errs, fmt.Errorf("%s.basic_auth.username: required when a password is set", path))
+	}
+	if a.BasicAuth.Username != "" && a.BasicAuth.Password == "" && a.BasicAuth.PasswordFile == "" {
+		errs = append(errs, fmt.Errorf("%s.basic_auth: password or password_file is required when a username is set", path))
+	}
+	if a.HMAC.Secret != "" && a.HMAC.SecretFile != "" {
+		errs = append(errs, fmt.Errorf("%s.hmac: only one of secret and secret_file may be set", path))
+	}
+	return errs
 }
 
+// checkFiles checks the secret files exist, prefixing problems with path
+func (a *Auth) checkFiles(path string) []error {
+	var errs []error
+	secretFiles := []struct{ path, file string }{
+		{path + ".bearer_token_file", a.BearerTokenFile},
+		{path + ".basic_auth.password_file", a.BasicAuth.PasswordFile},
+		{path + ".hmac.secret_file", a.HMAC.SecretFile},
+	}
+	for _, secretFile := range secretFiles {
+		if secretFile.file == "" {
+			continue
+		}
+		if _, err := os.Stat(secretFile.file); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", secretFile.path, err))
+		}
+	}
+	return errs
+}
+
+// ManualRuns configures the endpoints running rules and actions on demand
+type ManualRuns struct {
+	// Auth authenticates manual runs separately from webhooks, so the
+	// webhook's credentials can't run arbitrary actions. The endpoints
+	// are disabled until it's configured.
+	Auth Auth `json:"auth"`
+	// AllowedActions are the actions that can be run directly, none by default.
+	// Configured rules can always be run.
+	AllowedActions []string `json:"allowed_actions"`
+	// Cooldown and MaxExecutions limit direct action runs like the
+	// settings of the same name limit a rule
+	Cooldown      Duration       `json:"cooldown"`
+	MaxExecutions *MaxExecutions `json:"max_executions"`
+}
+
+// ManualRuleNamePrefix prefixes the action name in the rule name of direct action runs
+const ManualRuleNamePrefix = "manual:"
+
 type HTTP struct {
 	HTTPListener
-	Tracing
-	PProf          PProf    `json:"pprof"`
-	TrustedProxies []string `json:"trusted_proxies"`
-	Metrics        Metrics  `json:"metrics"`
+	Tracing        `json:"tracing"`
+	PProf          PProf      `json:"pprof"`
+	TrustedProxies []string   `json:"trusted_proxies"`
+	Metrics        Metrics    `json:"metrics"`
+	Auth           Auth       `json:"auth"`
+	ManualRuns     ManualRuns `json:"manual_runs"`
+	TLS            TLS        `json:"tls"`
 }
 
 type Labels map[string]string
 type Options map[string]string
 
+type MatchMode string
+
+const (
+	// MatchModeGroup matches rules against the webhook's common labels and
+	// executes the action once per notification
+	MatchModeGroup MatchMode = "group"
+	// MatchModeAlert matches rules against each alert's labels and
+	// executes the action once per matching alert
+	MatchModeAlert MatchMode = "alert"
+)
+
+// On is the alert status an action is executed on
+type On string
+
+const (
+	OnFiring   On = "firing"
+	OnResolved On = "resolved"
+	OnBoth     On = "both"
+)
+
+// Matches returns whether an alert or webhook with the given status
+// should trigger the action
+func (o On) Matches(status string) bool {
+	switch o {
+	case OnFiring, OnResolved:
+		return status == string(o)
+	case OnBoth:
+		return status == string(OnFiring) || status == string(OnResolved)
+	}
+	return false
+}
+
+// RetryOn is a class of errors that can be retried
+type RetryOn string
+
+const (
+	// RetryOnTimeout retries timed out operations
+	RetryOnTimeout RetryOn = "timeout"
+	// RetryOnNetwork retries network errors, like a refused SSH connection
+	RetryOnNetwork RetryOn = "network"
+	// RetryOnServer retries Kubernetes API server errors, throttling and conflicts
+	RetryOnServer RetryOn = "server"
+	// RetryOnDisruptionBudget retries evictions refused by a PodDisruptionBudget
+	RetryOnDisruptionBudget RetryOn = "disruption_budget"
+	// RetryOnAny retries every error
+	RetryOnAny RetryOn = "any"
+)
+
+type Retry struct {
+	// MaxAttempts is the total number of attempts, including the first one
+	MaxAttempts    int      `json:"max_attempts"`
+	InitialBackoff Duration `json:"initial_backoff"`
+	MaxBackoff     Duration `json:"max_backoff"`
+	// Jitter randomizes each backoff by up to this fraction of it
+	Jitter  float64   `json:"jitter"`
+	RetryOn []RetryOn `json:"retry_on"`
+}
+
+// MaxExecutions limits how often a rule executes in a sliding window
+type MaxExecutions struct {
+	Count  int      `json:"count"`
+	Window Duration `json:"window"`
+}
+
 type Action struct {
-	MatchCommonLabels Labels  `json:"match_common_labels"`
-	MatchGroupLabels  Labels  `json:"match_group_labels"`
-	Action            string  `json:"action"`
-	Options           Options `json:"options"`
+	// Name identifies the rule in logs and metrics
+	Name              string    `json:"name"`
+	MatchCommonLabels Labels    `json:"match_common_labels"`
+	MatchGroupLabels  Labels    `json:"match_group_labels"`
+	Matchers          Matchers  `json:"matchers"`
+	MatchMode         MatchMode `json:"match_mode"`
+	On                On        `json:"on"`
+	Action            string    `json:"action"`
+	Options           Options   `json:"options"`
+	Retry             Retry     `json:"retry"`
+	// Timeout bounds each attempt of the action
+	Timeout Duration `json:"timeout"`
+	// Cooldown suppresses executions against the same target for this long. The
+	// target is the rendered options with the defaults taken from the alert's labels.
+	Cooldown      Duration       `json:"cooldown"`
+	MaxExecutions *MaxExecutions `json:"max_executions"`
+	// DryRun logs what the action would do without doing it
+	DryRun bool `json:"dry_run"`
+	// RevertOnResolve restores what the action changed when the alert resolves
+	RevertOnResolve bool `json:"revert_on_resolve"`
+}
+
+func (a *Action) UnmarshalJSON(data []byte) error {
+	type action Action
+	var raw struct {
+		action
+		// YAML 1.1 parses an unquoted `on` key as the boolean true
+		YAMLOn On `json:"true"`
+	}
+	if err := unmarshalStrict(data, &raw); err != nil {
+		return err
+	}
+	*a = Action(raw.action)
+	if a.On == "" {
+		a.On = raw.YAMLOn
+	}
+	return nil
+}
+
+// unmarshalStrict unmarshals JSON, failing on unknown keys so that typos
+// in the config aren't silently ignored. Types with their own UnmarshalJSON
+// must use it too, as the decoder's setting doesn't reach them.
+func unmarshalStrict(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+type Workers struct {
+	// Concurrency is the number of actions executed at once
+	Concurrency int `json:"concurrency"`
+	// QueueSize is the number of pending actions before webhooks are rejected
+	QueueSize int `json:"queue_size"`
+	// ActionConcurrency limits the concurrency of individual action types
+	ActionConcurrency map[string]int `json:"action_concurrency"`
+	// DrainTimeout is how long to wait for queued actions on shutdown
+	// before in-flight actions are cancelled
+	DrainTimeout Duration `json:"drain_timeout"`
+}
+
+type HistoryBackend string
+
+const (
+	HistoryBackendMemory HistoryBackend = "memory"
+	HistoryBackendFile   HistoryBackend = "file"
+)
+
+type History struct {
+	Backend HistoryBackend `json:"backend"`
+	// Path is the file of the file backend
+	Path string `json:"path"`
+	// MaxEntries is the number of executions kept, -1 keeps all of them
+	MaxEntries int `json:"max_entries"`
+}
+
+type Deduplication struct {
+	// TTL is how long alert occurrences that were acted on are remembered
+	TTL Duration `json:"ttl"`
+}
+
+type Reload struct {
+	// Watch reloads the config when the config file changes
+	Watch bool `json:"watch"`
+	// Interval is how often the config file is checked for changes
+	Interval Duration `json:"interval"`
 }
 
 // Config is the main configuration for the application
 type Config struct {
-	HTTP    HTTP     `json:"http"`
+	HTTP          HTTP          `json:"http"`
+	Workers       Workers       `json:"workers"`
+	Deduplication Deduplication `json:"deduplication"`
+	History       History       `json:"history"`
+	Reload        Reload        `json:"reload"`
+	// DryRun runs every rule in dry run mode
+	DryRun  bool     `json:"dry_run"`
 	Actions []Action `json:"actions"`
 }
 
@@ -63,23 +385,77 @@ var (
 	HTTPPortKey            = "http.port"
 	HTTPTracingEnabledKey  = "http.tracing.enabled"
 	HTTPTracingOTLPEndKey  = "http.tracing.otlp_endpoint"
+	HTTPTracingOTLPProtKey = "http.tracing.otlp_protocol"
+	HTTPTracingOTLPInsKey  = "http.tracing.otlp_insecure"
 	HTTPPProfEnabledKey    = "http.pprof.enabled"
 	HTTPTrustedProxiesKey  = "http.trusted_proxies"
 	HTTPMetricsEnabledKey  = "http.metrics.enabled"
 	HTTPMetricsIPV4HostKey = "http.metrics.ipv4_host"
 	HTTPMetricsIPV6HostKey = "http.metrics.ipv6_host"
 	HTTPMetricsPortKey     = "http.metrics.port"
+	HTTPAuthBearerTokenKey = "http.auth.bearer_token"
+	HTTPAuthBearerFileKey  = "http.auth.bearer_token_file"
+	HTTPAuthBasicUserKey   = "http.auth.basic_auth.username"
+	HTTPAuthBasicPassKey   = "http.auth.basic_auth.password"
+	HTTPAuthBasicFileKey   = "http.auth.basic_auth.password_file"
+	HTTPAuthHMACSecretKey  = "http.auth.hmac.secret"
+	HTTPAuthHMACFileKey    = "http.auth.hmac.secret_file"
+	HTTPAuthHMACHeaderKey  = "http.auth.hmac.header"
+	HTTPManualTokenKey     = "http.manual_runs.auth.bearer_token"
+	HTTPManualTokenFileKey = "http.manual_runs.auth.bearer_token_file"
+	HTTPManualBasicUserKey = "http.manual_runs.auth.basic_auth.username"
+	HTTPManualBasicPassKey = "http.manual_runs.auth.basic_auth.password"
+	HTTPManualBasicFileKey = "http.manual_runs.auth.basic_auth.password_file"
+	HTTPManualHMACKey      = "http.manual_runs.auth.hmac.secret"
+	HTTPManualHMACFileKey  = "http.manual_runs.auth.hmac.secret_file"
+	HTTPManualHMACHdrKey   = "http.manual_runs.auth.hmac.header"
+	HTTPManualActionsKey   = "http.manual_runs.allowed_actions"
+	HTTPManualCooldownKey  = "http.manual_runs.cooldown"
+	HTTPTLSCertFileKey     = "http.tls.cert_file"
+	HTTPTLSKeyFileKey      = "http.tls.key_file"
+	HTTPTLSClientCAKey     = "http.tls.client_ca_file"
+	HTTPMetricsTLSCertKey  = "http.metrics.tls.cert_file"
+	HTTPMetricsTLSKeyKey   = "http.metrics.tls.key_file"
+	HTTPMetricsTLSCAKey    = "http.metrics.tls.client_ca_file"
+	WorkersConcurrencyKey  = "workers.concurrency"
+	WorkersQueueSizeKey    = "workers.queue_size"
+	WorkersDrainTimeoutKey = "workers.drain_timeout"
+	DeduplicationTTLKey    = "deduplication.ttl"
+	DryRunKey              = "dry_run"
+	HistoryBackendKey      = "history.backend"
+	HistoryPathKey         = "history.path"
+	HistoryMaxEntriesKey   = "history.max_entries"
+	ReloadWatchKey         = "reload.watch"
+	ReloadIntervalKey      = "reload.interval"
 )
 
 const (
-	DefaultHTTPIPV4Host        = "0.0.0.0"
-	DefaultHTTPIPV6Host        = "::"
-	DefaultHTTPPort            = 8080
-	DefaultHTTPMetricsIPV4Host = "127.0.0.1"
-	DefaultHTTPMetricsIPV6Host = "::1"
-	DefaultHTTPMetricsPort     = 8081
+	DefaultHTTPIPV4Host            = "0.0.0.0"
+	DefaultHTTPIPV6Host            = "::"
+	DefaultHTTPPort                = 8080
+	DefaultHTTPMetricsIPV4Host     = "127.0.0.1"
+	DefaultHTTPMetricsIPV6Host     = "::1"
+	DefaultHTTPMetricsPort         = 8081
+	DefaultHTTPTracingOTLPProtocol = TracingProtocolGRPC
+	DefaultHTTPAuthHMACHeader      = "X-Signature-256"
+	DefaultHTTPManualRunsCooldown  = Duration(time.Minute)
+	DefaultWorkersConcurrency      = 4
+	DefaultWorkersQueueSize        = 100
+	DefaultWorkersDrainTimeout     = Duration(30 * time.Second)
+	DefaultDeduplicationTTL        = Duration(7 * 24 * time.Hour)
+	DefaultHistoryBackend          = HistoryBackendMemory
+	DefaultHistoryPath             = "history.jsonl"
+	DefaultHistoryMaxEntries       = 10000
+	DefaultReloadInterval          = Duration(10 * time.Second)
+	DefaultActionTimeout           = Duration(time.Minute)
+	DefaultRetryMaxAttempts        = 1
+	DefaultRetryInitialBackoff     = Duration(time.Second)
+	DefaultRetryMaxBackoff         = Duration(30 * time.Second)
 )
 
+//nolint:golint,gochecknoglobals
+var DefaultRetryOn = []RetryOn{RetryOnTimeout, RetryOnNetwork, RetryOnServer}
+
 func RegisterFlags(cmd *cobra.Command) {
 	cmd.Flags().StringP(ConfigFileKey, "c", "", "Config file path")
 	cmd.Flags().String(HTTPIPV4HostKey, DefaultHTTPIPV4Host, "HTTP server IPv4 host")
@@ -87,20 +463,211 @@ func RegisterFlags(cmd *cobra.Command) {
 	cmd.Flags().Uint16(HTTPPortKey, DefaultHTTPPort, "HTTP server port")
 	cmd.Flags().Bool(HTTPTracingEnabledKey, false, "Enable Open Telemetry tracing")
 	cmd.Flags().String(HTTPTracingOTLPEndKey, "", "Open Telemetry endpoint")
+	cmd.Flags().String(HTTPTracingOTLPProtKey, string(DefaultHTTPTracingOTLPProtocol), "Open Telemetry protocol, grpc or http")
+	cmd.Flags().Bool(HTTPTracingOTLPInsKey, false, "Disable TLS for the Open Telemetry endpoint")
 	cmd.Flags().Bool(HTTPPProfEnabledKey, false, "Enable pprof")
 	cmd.Flags().StringSlice(HTTPTrustedProxiesKey, []string{}, "Comma-separated list of trusted proxies")
 	cmd.Flags().Bool(HTTPMetricsEnabledKey, false, "Enable metrics server")
 	cmd.Flags().String(HTTPMetricsIPV4HostKey, DefaultHTTPMetricsIPV4Host, "Metrics server IPv4 host")
 	cmd.Flags().String(HTTPMetricsIPV6HostKey, DefaultHTTPMetricsIPV6Host, "Metrics server IPv6 host")
 	cmd.Flags().Uint16(HTTPMetricsPortKey, DefaultHTTPMetricsPort, "Metrics server port")
+	cmd.Flags().String(HTTPTLSCertFileKey, "", "HTTP server TLS certificate file")
+	cmd.Flags().String(HTTPTLSKeyFileKey, "", "HTTP server TLS key file")
+	cmd.Flags().String(HTTPTLSClientCAKey, "", "CA bundle verifying HTTP server client certificates")
+	cmd.Flags().String(HTTPMetricsTLSCertKey, "", "Metrics server TLS certificate file")
+	cmd.Flags().String(HTTPMetricsTLSKeyKey, "", "Metrics server TLS key file")
+	cmd.Flags().String(HTTPMetricsTLSCAKey, "", "CA bundle verifying metrics server client certificates")
+	cmd.Flags().String(HTTPAuthBearerTokenKey, "", "Bearer token required by the webhook endpoint")
+	cmd.Flags().String(HTTPAuthBearerFileKey, "", "File with the bearer token required by the webhook endpoint")
+	cmd.Flags().String(HTTPAuthBasicUserKey, "", "Basic auth username required by the webhook endpoint")
+	cmd.Flags().String(HTTPAuthBasicPassKey, "", "Basic auth password required by the webhook endpoint")
+	cmd.Flags().String(HTTPAuthBasicFileKey, "", "File with the basic auth password required by the webhook endpoint")
+	cmd.Flags().String(HTTPAuthHMACSecretKey, "", "Secret of the HMAC-SHA256 signature required by the webhook endpoint")
+	cmd.Flags().String(HTTPAuthHMACFileKey, "", "File with the secret of the HMAC-SHA256 signature required by the webhook endpoint")
+	cmd.Flags().String(HTTPAuthHMACHeaderKey, DefaultHTTPAuthHMACHeader, "Header holding the HMAC-SHA256 signature of the webhook body")
+	cmd.Flags().String(HTTPManualTokenKey, "", "Bearer token required by the manual run endpoints")
+	cmd.Flags().String(HTTPManualTokenFileKey, "", "File with the bearer token required by the manual run endpoints")
+	cmd.Flags().String(HTTPManualBasicUserKey, "", "Basic auth username required by the manual run endpoints")
+	cmd.Flags().String(HTTPManualBasicPassKey, "", "Basic auth password required by the manual run endpoints")
+	cmd.Flags().String(HTTPManualBasicFileKey, "", "File with the basic auth password required by the manual run endpoints")
+	cmd.Flags().String(HTTPManualHMACKey, "", "Secret of the HMAC-SHA256 signature required by the manual run endpoints")
+	cmd.Flags().String(HTTPManualHMACFileKey, "", "File with the secret of the HMAC-SHA256 signature required by the manual run endpoints")
+	cmd.Flags().String(HTTPManualHMACHdrKey, DefaultHTTPAuthHMACHeader, "Header holding the HMAC-SHA256 signature of manual run bodies")
+	cmd.Flags().StringSlice(HTTPManualActionsKey, []string{}, "Comma-separated list of actions that can be run directly")
+	cmd.Flags().Duration(HTTPManualCooldownKey, time.Duration(DefaultHTTPManualRunsCooldown), "Cooldown of direct action runs against the same target")
+	cmd.Flags().Int(WorkersConcurrencyKey, DefaultWorkersConcurrency, "Number of actions executed concurrently")
+	cmd.Flags().Int(WorkersQueueSizeKey, DefaultWorkersQueueSize, "Number of queued actions before webhooks are rejected")
+	cmd.Flags().Duration(WorkersDrainTimeoutKey, time.Duration(DefaultWorkersDrainTimeout), "Time to wait for queued actions on shutdown")
+	cmd.Flags().Duration(DeduplicationTTLKey, time.Duration(DefaultDeduplicationTTL), "Time to remember alert occurrences that were acted on")
+	cmd.Flags().Bool(DryRunKey, false, "Log what actions would do without doing it")
+	cmd.Flags().String(HistoryBackendKey, string(DefaultHistoryBackend), "Execution history backend, memory or file")
+	cmd.Flags().String(HistoryPathKey, DefaultHistoryPath, "Execution history file for the file backend")
+	cmd.Flags().Int(HistoryMaxEntriesKey, DefaultHistoryMaxEntries, "Number of executions kept in the history, -1 keeps all of them")
+	cmd.Flags().Bool(ReloadWatchKey, false, "Reload the config when the config file changes")
+	cmd.Flags().Duration(ReloadIntervalKey, time.Duration(DefaultReloadInterval), "How often the config file is checked for changes")
 }
 
+// Validate checks the config for invalid values and reports every problem
+// found, each prefixed with the path of the offending field
+//
+//nolint:golint,gocyclo
 func (c *Config) Validate() error {
-	return nil
+	var errs []error
+	switch c.HTTP.Tracing.OTLPProtocol {
+	case TracingProtocolGRPC, TracingProtocolHTTP:
+	default:
+		errs = append(errs, fmt.Errorf("http.tracing.otlp_protocol: invalid value %q", c.HTTP.Tracing.OTLPProtocol))
+	}
+	if c.Workers.Concurrency < 1 {
+		errs = append(errs, fmt.Errorf("workers.concurrency: must be at least 1"))
+	}
+	if c.Workers.QueueSize < 0 {
+		errs = append(errs, fmt.Errorf("workers.queue_size: must not be negative"))
+	}
+	if c.Workers.DrainTimeout < 0 {
+		errs = append(errs, fmt.Errorf("workers.drain_timeout: must not be negative"))
+	}
+	if c.Deduplication.TTL < 0 {
+		errs = append(errs, fmt.Errorf("deduplication.ttl: must not be negative"))
+	}
+	switch c.History.Backend {
+	case HistoryBackendMemory, HistoryBackendFile:
+	default:
+		errs = append(errs, fmt.Errorf("history.backend: invalid value %q", c.History.Backend))
+	}
+	if c.History.MaxEntries < -1 {
+		errs = append(errs, fmt.Errorf("history.max_entries: must be -1 or more"))
+	}
+	errs = append(errs, c.HTTP.Auth.validate("http.auth")...)
+	errs = append(errs, c.HTTP.ManualRuns.Auth.validate("http.manual_runs.auth")...)
+	if c.HTTP.ManualRuns.Cooldown < 0 {
+		errs = append(errs, fmt.Errorf("http.manual_runs.cooldown: must not be negative"))
+	}
+	if maxExecutions := c.HTTP.ManualRuns.MaxExecutions; maxExecutions != nil && (maxExecutions.Count < 1 || maxExecutions.Window <= 0) {
+		errs = append(errs, fmt.Errorf("http.manual_runs.max_executions: needs a count of at least 1 and a positive window"))
+	}
+	errs = append(errs, c.HTTP.TLS.validate("http.tls")...)
+	if c.HTTP.Metrics.Enabled {
+		errs = append(errs, c.HTTP.Metrics.TLS.validate("http.metrics.tls")...)
+	}
+	if c.Reload.Interval < 0 {
+		errs = append(errs, fmt.Errorf("reload.interval: must not be negative"))
+	}
+	if c.HTTP.Metrics.Enabled && c.HTTP.Metrics.Port == c.HTTP.Port {
+		if listenersCollide(c.HTTP.IPV4Host, c.HTTP.Metrics.IPV4Host, "0.0.0.0") {
+			errs = append(errs, fmt.Errorf("http.metrics: %s:%d collides with the API listener", c.HTTP.Metrics.IPV4Host, c.HTTP.Metrics.Port))
+		}
+		if listenersCollide(c.HTTP.IPV6Host, c.HTTP.Metrics.IPV6Host, "::") {
+			errs = append(errs, fmt.Errorf("http.metrics: [%s]:%d collides with the API listener", c.HTTP.Metrics.IPV6Host, c.HTTP.Metrics.Port))
+		}
+	}
+	for action, limit := range c.Workers.ActionConcurrency {
+		if limit < 1 {
+			errs = append(errs, fmt.Errorf("workers.action_concurrency.%s: must be at least 1", action))
+		}
+	}
+	names := make(map[string]bool, len(c.Actions))
+	for i, action := range c.Actions {
+		if names[action.Name] {
+			errs = append(errs, fmt.Errorf("actions[%d].name: duplicate name %q", i, action.Name))
+		}
+		names[action.Name] = true
+		if strings.HasPrefix(action.Name, ManualRuleNamePrefix) {
+			errs = append(errs, fmt.Errorf("actions[%d].name: the %q prefix is reserved for manual action runs", i, ManualRuleNamePrefix))
+		}
+		switch action.MatchMode {
+		case MatchModeGroup, MatchModeAlert:
+		default:
+			errs = append(errs, fmt.Errorf("actions[%d].match_mode: invalid value %q", i, action.MatchMode))
+		}
+		switch action.On {
+		case OnFiring, OnResolved, OnBoth:
+		default:
+			errs = append(errs, fmt.Errorf("actions[%d].on: invalid value %q", i, action.On))
+		}
+		if action.Timeout < 0 {
+			errs = append(errs, fmt.Errorf("actions[%d].timeout: must not be negative", i))
+		}
+		if action.Cooldown < 0 {
+			errs = append(errs, fmt.Errorf("actions[%d].cooldown: must not be negative", i))
+		}
+		if action.MaxExecutions != nil && (action.MaxExecutions.Count < 1 || action.MaxExecutions.Window <= 0) {
+			errs = append(errs, fmt.Errorf("actions[%d].max_executions: needs a count of at least 1 and a positive window", i))
+		}
+		if action.Retry.MaxAttempts < 1 {
+			errs = append(errs, fmt.Errorf("actions[%d].retry.max_attempts: must be at least 1", i))
+		}
+		if action.Retry.InitialBackoff <= 0 || action.Retry.MaxBackoff < action.Retry.InitialBackoff {
+			errs = append(errs, fmt.Errorf("actions[%d].retry: backoffs must be positive and max_backoff at least initial_backoff", i))
+		}
+		if action.Retry.Jitter < 0 || action.Retry.Jitter > 1 {
+			errs = append(errs, fmt.Errorf("actions[%d].retry.jitter: must be between 0 and 1", i))
+		}
+		if action.RevertOnResolve {
+			// Reverts are tracked per alert fingerprint and undo what the firing alert did
+			if action.MatchMode != MatchModeAlert {
+				errs = append(errs, fmt.Errorf("actions[%d].revert_on_resolve: requires match_mode %q", i, MatchModeAlert))
+			}
+			if action.On != OnFiring {
+				errs = append(errs, fmt.Errorf("actions[%d].revert_on_resolve: requires on %q", i, OnFiring))
+			}
+		}
+		for _, retryOn := range action.Retry.RetryOn {
+			switch retryOn {
+			case RetryOnTimeout, RetryOnNetwork, RetryOnServer, RetryOnDisruptionBudget, RetryOnAny:
+			default:
+				errs = append(errs, fmt.Errorf("actions[%d].retry.retry_on: invalid value %q", i, retryOn))
+			}
+		}
+	}
+	return errors.Join(errs...)
 }
 
-//nolint:golint,gocyclo
+// listenersCollide reports whether two hosts on the same port would
+// conflict, given the address that listens on every interface
+func listenersCollide(a, b, wildcard string) bool {
+	ipA, ipB := net.ParseIP(a), net.ParseIP(b)
+	if ipA != nil && ipB != nil {
+		ipWildcard := net.ParseIP(wildcard)
+		return ipA.Equal(ipB) || ipA.Equal(ipWildcard) || ipB.Equal(ipWildcard)
+	}
+	return a == b || a == wildcard || b == wildcard
+}
+
+// CheckFiles checks the files the config refers to can be read, reporting
+// every problem found like Validate. It's separate from Validate so that a
+// config can be validated without access to its secrets and certificates.
+func (c *Config) CheckFiles() error {
+	var errs []error
+	errs = append(errs, c.HTTP.Auth.checkFiles("http.auth")...)
+	errs = append(errs, c.HTTP.ManualRuns.Auth.checkFiles("http.manual_runs.auth")...)
+	errs = append(errs, c.HTTP.TLS.checkFiles("http.tls")...)
+	if c.HTTP.Metrics.Enabled {
+		errs = append(errs, c.HTTP.Metrics.TLS.checkFiles("http.metrics.tls")...)
+	}
+	return errors.Join(errs...)
+}
+
+// LoadConfig loads the config from the config file, flags and
+// environment and validates it
 func LoadConfig(cmd *cobra.Command) (*Config, error) {
+	config, err := LoadUnvalidatedConfig(cmd)
+	if err != nil {
+		return config, err
+	}
+
+	err = config.Validate()
+	if err != nil {
+		return config, fmt.Errorf("failed to validate config: %w", err)
+	}
+
+	return config, nil
+}
+
+// LoadUnvalidatedConfig loads the config like LoadConfig without validating it
+//
+//nolint:golint,gocyclo
+func LoadUnvalidatedConfig(cmd *cobra.Command) (*Config, error) {
 	var config Config
 
 	// Load flags from envs
@@ -131,7 +698,14 @@ func LoadConfig(cmd *cobra.Command) (*Config, error) {
 			return &config, fmt.Errorf("failed to read config: %w", err)
 		}
 
-		if err := yaml.Unmarshal(data, &config); err != nil {
+		jsonData, err := yaml.YAMLToJSON(data)
+		if err != nil {
+			return &config, fmt.Errorf("failed to unmarshal config: %w", err)
+		}
+		if err := checkUnknownKeys(jsonData, &config); err != nil {
+			return &config, fmt.Errorf("failed to unmarshal config: %w", err)
+		}
+		if err := unmarshalStrict(jsonData, &config); err != nil {
 			return &config, fmt.Errorf("failed to unmarshal config: %w", err)
 		}
 	}
@@ -200,6 +774,175 @@ func LoadConfig(cmd *cobra.Command) (*Config, error) {
 		}
 	}
 
+	if cmd.Flags().Changed(HTTPTLSCertFileKey) {
+		config.HTTP.TLS.CertFile, err = cmd.Flags().GetString(HTTPTLSCertFileKey)
+		if err != nil {
+			return &config, fmt.Errorf("failed to get HTTP TLS certificate file: %w", err)
+		}
+	}
+
+	if cmd.Flags().Changed(HTTPTLSKeyFileKey) {
+		config.HTTP.TLS.KeyFile, err = cmd.Flags().GetString(HTTPTLSKeyFileKey)
+		if err != nil {
+			return &config, fmt.Errorf("failed to get HTTP TLS key file: %w", err)
+		}
+	}
+
+	if cmd.Flags().Changed(HTTPTLSClientCAKey) {
+		config.HTTP.TLS.ClientCAFile, err = cmd.Flags().GetString(HTTPTLSClientCAKey)
+		if err != nil {
+			return &config, fmt.Errorf("failed to get HTTP TLS client CA file: %w", err)
+		}
+	}
+
+	if cmd.Flags().Changed(HTTPMetricsTLSCertKey) {
+		config.HTTP.Metrics.TLS.CertFile, err = cmd.Flags().GetString(HTTPMetricsTLSCertKey)
+		if err != nil {
+			return &config, fmt.Errorf("failed to get metrics TLS certificate file: %w", err)
+		}
+	}
+
+	if cmd.Flags().Changed(HTTPMetricsTLSKeyKey) {
+		config.HTTP.Metrics.TLS.KeyFile, err = cmd.Flags().GetString(HTTPMetricsTLSKeyKey)
+		if err != nil {
+			return &config, fmt.Errorf("failed to get metrics TLS key file: %w", err)
+		}
+	}
+
+	if cmd.Flags().Changed(HTTPMetricsTLSCAKey) {
+		config.HTTP.Metrics.TLS.ClientCAFile, err = cmd.Flags().GetString(HTTPMetricsTLSCAKey)
+		if err != nil {
+			return &config, fmt.Errorf("failed to get metrics TLS client CA file: %w", err)
+		}
+	}
+
+	if cmd.Flags().Changed(HTTPAuthBearerTokenKey) {
+		config.HTTP.Auth.BearerToken, err = cmd.Flags().GetString(HTTPAuthBearerTokenKey)
+		if err != nil {
+			return &config, fmt.Errorf("failed to get auth bearer token: %w", err)
+		}
+	}
+
+	if cmd.Flags().Changed(HTTPAuthBearerFileKey) {
+		config.HTTP.Auth.BearerTokenFile, err = cmd.Flags().GetString(HTTPAuthBearerFileKey)
+		if err != nil {
+			return &config, fmt.Errorf("failed to get auth bearer token file: %w", err)
+		}
+	}
+
+	if cmd.Flags().Changed(HTTPAuthBasicUserKey) {
+		config.HTTP.Auth.BasicAuth.Username, err = cmd.Flags().GetString(HTTPAuthBasicUserKey)
+		if err != nil {
+			return &config, fmt.Errorf("failed to get auth basic auth username: %w", err)
+		}
+	}
+
+	if cmd.Flags().Changed(HTTPAuthBasicPassKey) {
+		config.HTTP.Auth.BasicAuth.Password, err = cmd.Flags().GetString(HTTPAuthBasicPassKey)
+		if err != nil {
+			return &config, fmt.Errorf("failed to get auth basic auth password: %w", err)
+		}
+	}
+
+	if cmd.Flags().Changed(HTTPAuthBasicFileKey) {
+		config.HTTP.Auth.BasicAuth.PasswordFile, err = cmd.Flags().GetString(HTTPAuthBasicFileKey)
+		if err != nil {
+			return &config, fmt.Errorf("failed to get auth basic auth password file: %w", err)
+		}
+	}
+
+	if cmd.Flags().Changed(HTTPAuthHMACSecretKey) {
+		config.HTTP.Auth.HMAC.Secret, err = cmd.Flags().GetString(HTTPAuthHMACSecretKey)
+		if err != nil {
+			return &config, fmt.Errorf("failed to get auth HMAC secret: %w", err)
+		}
+	}
+
+	if cmd.Flags().Changed(HTTPAuthHMACFileKey) {
+		config.HTTP.Auth.HMAC.SecretFile, err = cmd.Flags().GetString(HTTPAuthHMACFileKey)
+		if err != nil {
+			return &config, fmt.Errorf("failed to get auth HMAC secret file: %w", err)
+		}
+	}
+
+	if cmd.Flags().Changed(HTTPAuthHMACHeaderKey) {
+		config.HTTP.Auth.HMAC.Header, err = cmd.Flags().GetString(HTTPAuthHMACHeaderKey)
+		if err != nil {
+			return &config, fmt.Errorf("failed to get auth HMAC header: %w", err)
+		}
+	}
+
+	if cmd.Flags().Changed(HTTPManualTokenKey) {
+		config.HTTP.ManualRuns.Auth.BearerToken, err = cmd.Flags().GetString(HTTPManualTokenKey)
+		if err != nil {
+			return &config, fmt.Errorf("failed to get manual runs bearer token: %w", err)
+		}
+	}
+
+	if cmd.Flags().Changed(HTTPManualTokenFileKey) {
+		config.HTTP.ManualRuns.Auth.BearerTokenFile, err = cmd.Flags().GetString(HTTPManualTokenFileKey)
+		if err != nil {
+			return &config, fmt.Errorf("failed to get manual runs bearer token file: %w", err)
+		}
+	}
+
+	if cmd.Flags().Changed(HTTPManualBasicUserKey) {
+		config.HTTP.ManualRuns.Auth.BasicAuth.Username, err = cmd.Flags().GetString(HTTPManualBasicUserKey)
+		if err != nil {
+			return &config, fmt.Errorf("failed to get manual runs basic auth username: %w", err)
+		}
+	}
+
+	if cmd.Flags().Changed(HTTPManualBasicPassKey) {
+		config.HTTP.ManualRuns.Auth.BasicAuth.Password, err = cmd.Flags().GetString(HTTPManualBasicPassKey)
+		if err != nil {
+			return &config, fmt.Errorf("failed to get manual runs basic auth password: %w", err)
+		}
+	}
+
+	if cmd.Flags().Changed(HTTPManualBasicFileKey) {
+		config.HTTP.ManualRuns.Auth.BasicAuth.PasswordFile, err = cmd.Flags().GetString(HTTPManualBasicFileKey)
+		if err != nil {
+			return &config, fmt.Errorf("failed to get manual runs basic auth password file: %w", err)
+		}
+	}
+
+	if cmd.Flags().Changed(HTTPManualHMACKey) {
+		config.HTTP.ManualRuns.Auth.HMAC.Secret, err = cmd.Flags().GetString(HTTPManualHMACKey)
+		if err != nil {
+			return &config, fmt.Errorf("failed to get manual runs HMAC secret: %w", err)
+		}
+	}
+
+	if cmd.Flags().Changed(HTTPManualHMACFileKey) {
+		config.HTTP.ManualRuns.Auth.HMAC.SecretFile, err = cmd.Flags().GetString(HTTPManualHMACFileKey)
+		if err != nil {
+			return &config, fmt.Errorf("failed to get manual runs HMAC secret file: %w", err)
+		}
+	}
+
+	if cmd.Flags().Changed(HTTPManualHMACHdrKey) {
+		config.HTTP.ManualRuns.Auth.HMAC.Header, err = cmd.Flags().GetString(HTTPManualHMACHdrKey)
+		if err != nil {
+			return &config, fmt.Errorf("failed to get manual runs HMAC header: %w", err)
+		}
+	}
+
+	if cmd.Flags().Changed(HTTPManualActionsKey) {
+		config.HTTP.ManualRuns.AllowedActions, err = cmd.Flags().GetStringSlice(HTTPManualActionsKey)
+		if err != nil {
+			return &config, fmt.Errorf("failed to get manual runs allowed actions: %w", err)
+		}
+	}
+
+	if cmd.Flags().Changed(HTTPManualCooldownKey) {
+		cooldown, err := cmd.Flags().GetDuration(HTTPManualCooldownKey)
+		if err != nil {
+			return &config, fmt.Errorf("failed to get manual runs cooldown: %w", err)
+		}
+		config.HTTP.ManualRuns.Cooldown = Duration(cooldown)
+	}
+
 	if cmd.Flags().Changed(HTTPTracingEnabledKey) {
 		config.HTTP.Tracing.Enabled, err = cmd.Flags().GetBool(HTTPTracingEnabledKey)
 		if err != nil {
@@ -214,6 +957,95 @@ func LoadConfig(cmd *cobra.Command) (*Config, error) {
 		}
 	}
 
+	if cmd.Flags().Changed(HTTPTracingOTLPProtKey) {
+		protocol, err := cmd.Flags().GetString(HTTPTracingOTLPProtKey)
+		if err != nil {
+			return &config, fmt.Errorf("failed to get tracing OTLP protocol: %w", err)
+		}
+		config.HTTP.Tracing.OTLPProtocol = TracingProtocol(protocol)
+	}
+
+	if cmd.Flags().Changed(HTTPTracingOTLPInsKey) {
+		config.HTTP.Tracing.OTLPInsecure, err = cmd.Flags().GetBool(HTTPTracingOTLPInsKey)
+		if err != nil {
+			return &config, fmt.Errorf("failed to get tracing OTLP insecure: %w", err)
+		}
+	}
+
+	if cmd.Flags().Changed(WorkersConcurrencyKey) {
+		config.Workers.Concurrency, err = cmd.Flags().GetInt(WorkersConcurrencyKey)
+		if err != nil {
+			return &config, fmt.Errorf("failed to get workers concurrency: %w", err)
+		}
+	}
+
+	if cmd.Flags().Changed(WorkersQueueSizeKey) {
+		config.Workers.QueueSize, err = cmd.Flags().GetInt(WorkersQueueSizeKey)
+		if err != nil {
+			return &config, fmt.Errorf("failed to get workers queue size: %w", err)
+		}
+	}
+
+	if cmd.Flags().Changed(WorkersDrainTimeoutKey) {
+		drainTimeout, err := cmd.Flags().GetDuration(WorkersDrainTimeoutKey)
+		if err != nil {
+			return &config, fmt.Errorf("failed to get workers drain timeout: %w", err)
+		}
+		config.Workers.DrainTimeout = Duration(drainTimeout)
+	}
+
+	if cmd.Flags().Changed(DeduplicationTTLKey) {
+		ttl, err := cmd.Flags().GetDuration(DeduplicationTTLKey)
+		if err != nil {
+			return &config, fmt.Errorf("failed to get deduplication TTL: %w", err)
+		}
+		config.Deduplication.TTL = Duration(ttl)
+	}
+
+	if cmd.Flags().Changed(DryRunKey) {
+		config.DryRun, err = cmd.Flags().GetBool(DryRunKey)
+		if err != nil {
+			return &config, fmt.Errorf("failed to get dry run: %w", err)
+		}
+	}
+
+	if cmd.Flags().Changed(HistoryBackendKey) {
+		backend, err := cmd.Flags().GetString(HistoryBackendKey)
+		if err != nil {
+			return &config, fmt.Errorf("failed to get history backend: %w", err)
+		}
+		config.History.Backend = HistoryBackend(backend)
+	}
+
+	if cmd.Flags().Changed(HistoryPathKey) {
+		config.History.Path, err = cmd.Flags().GetString(HistoryPathKey)
+		if err != nil {
+			return &config, fmt.Errorf("failed to get history path: %w", err)
+		}
+	}
+
+	if cmd.Flags().Changed(HistoryMaxEntriesKey) {
+		config.History.MaxEntries, err = cmd.Flags().GetInt(HistoryMaxEntriesKey)
+		if err != nil {
+			return &config, fmt.Errorf("failed to get history max entries: %w", err)
+		}
+	}
+
+	if cmd.Flags().Changed(ReloadWatchKey) {
+		config.Reload.Watch, err = cmd.Flags().GetBool(ReloadWatchKey)
+		if err != nil {
+			return &config, fmt.Errorf("failed to get reload watch: %w", err)
+		}
+	}
+
+	if cmd.Flags().Changed(ReloadIntervalKey) {
+		interval, err := cmd.Flags().GetDuration(ReloadIntervalKey)
+		if err != nil {
+			return &config, fmt.Errorf("failed to get reload interval: %w", err)
+		}
+		config.Reload.Interval = Duration(interval)
+	}
+
 	// Defaults
 	if config.HTTP.IPV4Host == "" {
 		config.HTTP.IPV4Host = DefaultHTTPIPV4Host
@@ -233,10 +1065,68 @@ func LoadConfig(cmd *cobra.Command) (*Config, error) {
 	if config.HTTP.Metrics.Port == 0 {
 		config.HTTP.Metrics.Port = DefaultHTTPMetricsPort
 	}
-
-	err = config.Validate()
-	if err != nil {
-		return &config, fmt.Errorf("failed to validate config: %w", err)
+	if config.HTTP.Auth.HMAC.Header == "" {
+		config.HTTP.Auth.HMAC.Header = DefaultHTTPAuthHMACHeader
+	}
+	if config.HTTP.ManualRuns.Auth.HMAC.Header == "" {
+		config.HTTP.ManualRuns.Auth.HMAC.Header = DefaultHTTPAuthHMACHeader
+	}
+	if config.HTTP.ManualRuns.Cooldown == 0 {
+		config.HTTP.ManualRuns.Cooldown = DefaultHTTPManualRunsCooldown
+	}
+	if config.HTTP.Tracing.OTLPProtocol == "" {
+		config.HTTP.Tracing.OTLPProtocol = DefaultHTTPTracingOTLPProtocol
+	}
+	if config.Workers.Concurrency == 0 {
+		config.Workers.Concurrency = DefaultWorkersConcurrency
+	}
+	if config.Workers.QueueSize == 0 {
+		config.Workers.QueueSize = DefaultWorkersQueueSize
+	}
+	if config.Workers.DrainTimeout == 0 {
+		config.Workers.DrainTimeout = DefaultWorkersDrainTimeout
+	}
+	if config.Deduplication.TTL == 0 {
+		config.Deduplication.TTL = DefaultDeduplicationTTL
+	}
+	if config.History.Backend == "" {
+		config.History.Backend = DefaultHistoryBackend
+	}
+	if config.History.Path == "" {
+		config.History.Path = DefaultHistoryPath
+	}
+	if config.History.MaxEntries == 0 {
+		config.History.MaxEntries = DefaultHistoryMaxEntries
+	}
+	if config.Reload.Interval == 0 {
+		config.Reload.Interval = DefaultReloadInterval
+	}
+	for i := range config.Actions {
+		if config.Actions[i].MatchMode == "" {
+			config.Actions[i].MatchMode = MatchModeGroup
+		}
+		if config.Actions[i].Name == "" {
+			config.Actions[i].Name = fmt.Sprintf("%s-%d", config.Actions[i].Action, i)
+		}
+		if config.Actions[i].On == "" {
+			config.Actions[i].On = OnFiring
+		}
+		if config.Actions[i].Timeout == 0 {
+			config.Actions[i].Timeout = DefaultActionTimeout
+		}
+		retry := &config.Actions[i].Retry
+		if retry.MaxAttempts == 0 {
+			retry.MaxAttempts = DefaultRetryMaxAttempts
+		}
+		if retry.InitialBackoff == 0 {
+			retry.InitialBackoff = DefaultRetryInitialBackoff
+		}
+		if retry.MaxBackoff == 0 {
+			retry.MaxBackoff = max(DefaultRetryMaxBackoff, retry.InitialBackoff)
+		}
+		if retry.RetryOn == nil {
+			retry.RetryOn = DefaultRetryOn
+		}
 	}
 
 	return &config, nil
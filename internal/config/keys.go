@@ -0,0 +1,106 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// checkUnknownKeys reports every key of the JSON config that doesn't match
+// a field of v, with the path of the key. The decoder only reports the
+// first unknown key, without saying where it is.
+func checkUnknownKeys(data []byte, v any) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	return errors.Join(unknownKeys("", value, reflect.TypeOf(v))...)
+}
+
+func unknownKeys(path string, value any, t reflect.Type) []error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	var errs []error
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]any)
+		if !ok {
+			// Types that unmarshal from other values, like matchers, have no keys
+			return nil
+		}
+		fields := jsonFields(t)
+		for _, key := range slices.Sorted(maps.Keys(object)) {
+			// Keys match fields regardless of case, like the decoder does
+			field, ok := fields[strings.ToLower(key)]
+			if !ok && key == "true" {
+				// YAML 1.1 parses an unquoted `on` key as the boolean true
+				field, ok = fields["on"]
+			}
+			if !ok {
+				errs = append(errs, fmt.Errorf("%s: unknown key", joinPath(path, key)))
+				continue
+			}
+			errs = append(errs, unknownKeys(joinPath(path, key), object[key], field)...)
+		}
+	case reflect.Map:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		for _, key := range slices.Sorted(maps.Keys(object)) {
+			errs = append(errs, unknownKeys(joinPath(path, key), object[key], t.Elem())...)
+		}
+	case reflect.Slice, reflect.Array:
+		list, ok := value.([]any)
+		if !ok {
+			return nil
+		}
+		for i, item := range list {
+			errs = append(errs, unknownKeys(fmt.Sprintf("%s[%d]", path, i), item, t.Elem())...)
+		}
+	default:
+	}
+	return errs
+}
+
+// jsonFields returns the types of a struct's fields by their lowercased
+// JSON key, including the fields of embedded structs without a key of
+// their own
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			for embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				maps.Copy(fields, jsonFields(embedded))
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[strings.ToLower(name)] = field.Type
+	}
+	return fields
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
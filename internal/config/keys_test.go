@@ -0,0 +1,46 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckUnknownKeys(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		json string
+		want []string
+	}{
+		{name: "known keys", json: `{"dry_run": true, "actions": [{"name": "r", "cooldown": "5m"}]}`},
+		{name: "top level", json: `{"dryrun": true}`, want: []string{"dryrun: unknown key"}},
+		{name: "nested", json: `{"http": {"tls": {"cert": ""}}}`, want: []string{"http.tls.cert: unknown key"}},
+		{name: "action", json: `{"actions": [{"name": "r"}, {"cooldwn": "5m"}]}`, want: []string{"actions[1].cooldwn: unknown key"}},
+		{name: "every key", json: `{"actions": [{"cooldwn": "5m", "retyr": {}}], "http": {"prot": 80}}`, want: []string{
+			"actions[0].cooldwn: unknown key",
+			"actions[0].retyr: unknown key",
+			"http.prot: unknown key",
+		}},
+		// Options and action_concurrency are maps with any keys
+		{name: "map keys", json: `{"actions": [{"options": {"anything": "x"}}], "workers": {"action_concurrency": {"ssh": 1}}}`},
+		{name: "embedded listener", json: `{"http": {"port": 8080, "metrics": {"port": 9000, "enabled": true}}}`},
+		{name: "yaml on key", json: `{"actions": [{"true": "resolved"}]}`},
+		{name: "case insensitive", json: `{"DRY_RUN": true}`},
+		{name: "matchers", json: `{"actions": [{"matchers": ["severity=\"critical\""]}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := checkUnknownKeys([]byte(tt.json), &Config{})
+			var got []string
+			if err != nil {
+				got = strings.Split(err.Error(), "\n")
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("checkUnknownKeys(%s) = %q, want %q", tt.json, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// MatchType is the operator of a label matcher, following the
+// Alertmanager matcher syntax.
+type MatchType string
+
+const (
+	MatchEqual     MatchType = "="
+	MatchNotEqual  MatchType = "!="
+	MatchRegexp    MatchType = "=~"
+	MatchNotRegexp MatchType = "!~"
+)
+
+// https://prometheus.io/docs/alerting/latest/configuration/#matcher
+var matcherRegexp = regexp.MustCompile(`^\s*([a-zA-Z_][a-zA-Z0-9_]*)\s*(=~|!~|!=|=)\s*(.*?)\s*$`) //nolint:golint,gochecknoglobals
+
+// Matcher matches a single label against a value or regular expression.
+type Matcher struct {
+	Name  string
+	Type  MatchType
+	Value string
+	re    *regexp.Regexp
+}
+
+// ParseMatcher parses a matcher in the Alertmanager `name<op>"value"` syntax,
+// e.g. `severity=~"critical|page"` or `namespace!="kube-system"`.
+// Values may be left unquoted.
+func ParseMatcher(s string) (*Matcher, error) {
+	parts := matcherRegexp.FindStringSubmatch(s)
+	if parts == nil {
+		return nil, fmt.Errorf("invalid matcher %q", s)
+	}
+
+	value := parts[3]
+	if strings.HasPrefix(value, `"`) {
+		var err error
+		value, err = strconv.Unquote(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid matcher %q: bad quoting: %w", s, err)
+		}
+	}
+
+	m := &Matcher{
+		Name:  parts[1],
+		Type:  MatchType(parts[2]),
+		Value: value,
+	}
+
+	if m.Type == MatchRegexp || m.Type == MatchNotRegexp {
+		// Like Prometheus, regular expressions are fully anchored
+		re, err := regexp.Compile("^(?:" + value + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid matcher %q: %w", s, err)
+		}
+		m.re = re
+	}
+
+	return m, nil
+}
+
+// Matches returns whether the given label value satisfies the matcher.
+func (m *Matcher) Matches(value string) bool {
+	switch m.Type {
+	case MatchEqual:
+		return value == m.Value
+	case MatchNotEqual:
+		return value != m.Value
+	case MatchRegexp:
+		return m.re.MatchString(value)
+	case MatchNotRegexp:
+		return !m.re.MatchString(value)
+	}
+	return false
+}
+
+func (m *Matcher) String() string {
+	return fmt.Sprintf("%s%s%q", m.Name, m.Type, m.Value)
+}
+
+func (m *Matcher) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := ParseMatcher(s)
+	if err != nil {
+		return err
+	}
+	*m = *parsed
+	return nil
+}
+
+func (m *Matcher) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.String())
+}
+
+// Matchers is a list of matchers that must all match.
+type Matchers []*Matcher
+
+// Mismatch returns the first matcher the labels don't satisfy, or nil if
+// they satisfy all of them. A missing label is treated as an empty value,
+// as Alertmanager does.
+func (ms Matchers) Mismatch(labels map[string]string) *Matcher {
+	for _, m := range ms {
+		if !m.Matches(labels[m.Name]) {
+			return m
+		}
+	}
+	return nil
+}
+
+func (ms *Matchers) UnmarshalJSON(data []byte) error {
+	var raw []string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("matchers must be a list of strings: %w", err)
+	}
+	matchers := make(Matchers, 0, len(raw))
+	for i, s := range raw {
+		m, err := ParseMatcher(s)
+		if err != nil {
+			return fmt.Errorf("matchers[%d]: %w", i, err)
+		}
+		matchers = append(matchers, m)
+	}
+	*ms = matchers
+	return nil
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseMatcher(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		matcher string
+		want    Matcher
+		wantErr bool
+	}{
+		{name: "equal", matcher: `severity="critical"`, want: Matcher{Name: "severity", Type: MatchEqual, Value: "critical"}},
+		{name: "not equal", matcher: `namespace!="kube-system"`, want: Matcher{Name: "namespace", Type: MatchNotEqual, Value: "kube-system"}},
+		{name: "regexp", matcher: `severity=~"critical|page"`, want: Matcher{Name: "severity", Type: MatchRegexp, Value: "critical|page"}},
+		{name: "not regexp", matcher: `pod!~"test-.*"`, want: Matcher{Name: "pod", Type: MatchNotRegexp, Value: "test-.*"}},
+		{name: "unquoted", matcher: `severity=critical`, want: Matcher{Name: "severity", Type: MatchEqual, Value: "critical"}},
+		{name: "spaces", matcher: ` severity = "critical" `, want: Matcher{Name: "severity", Type: MatchEqual, Value: "critical"}},
+		{name: "escaped quote", matcher: `summary="say \"hi\""`, want: Matcher{Name: "summary", Type: MatchEqual, Value: `say "hi"`}},
+		{name: "empty value", matcher: `team=""`, want: Matcher{Name: "team", Type: MatchEqual, Value: ""}},
+		{name: "no operator", matcher: `severity`, wantErr: true},
+		{name: "invalid name", matcher: `1severity="critical"`, wantErr: true},
+		{name: "bad quoting", matcher: `severity="critical`, wantErr: true},
+		{name: "invalid regexp", matcher: `severity=~"(critical"`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			m, err := ParseMatcher(tt.matcher)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseMatcher(%q) = %v, want an error", tt.matcher, m)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseMatcher(%q): %v", tt.matcher, err)
+			}
+			if m.Name != tt.want.Name || m.Type != tt.want.Type || m.Value != tt.want.Value {
+				t.Errorf("ParseMatcher(%q) = %s %s %q, want %s %s %q", tt.matcher, m.Name, m.Type, m.Value, tt.want.Name, tt.want.Type, tt.want.Value)
+			}
+		})
+	}
+}
+
+func TestMatcherMatches(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		matcher string
+		value   string
+		want    bool
+	}{
+		{matcher: `severity="critical"`, value: "critical", want: true},
+		{matcher: `severity="critical"`, value: "warning", want: false},
+		{matcher: `severity!="critical"`, value: "warning", want: true},
+		{matcher: `severity!="critical"`, value: "critical", want: false},
+		{matcher: `severity=~"critical|page"`, value: "page", want: true},
+		// Regular expressions are fully anchored
+		{matcher: `severity=~"crit"`, value: "critical", want: false},
+		{matcher: `severity=~"crit.*"`, value: "critical", want: true},
+		{matcher: `pod!~"test-.*"`, value: "test-1", want: false},
+		{matcher: `pod!~"test-.*"`, value: "web-1", want: true},
+		{matcher: `team=""`, value: "", want: true},
+		{matcher: `team=~".+"`, value: "", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.matcher+" "+tt.value, func(t *testing.T) {
+			t.Parallel()
+
+			m, err := ParseMatcher(tt.matcher)
+			if err != nil {
+				t.Fatalf("ParseMatcher(%q): %v", tt.matcher, err)
+			}
+			if got := m.Matches(tt.value); got != tt.want {
+				t.Errorf("%s.Matches(%q) = %v, want %v", tt.matcher, tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchersUnmarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		json   string
+		labels map[string]string
+		// want is the name of the first matcher that doesn't match
+		want    string
+		wantErr bool
+	}{
+		{name: "all match", json: `["severity=\"critical\"", "namespace=~\"prod-.*\""]`, labels: map[string]string{"severity": "critical", "namespace": "prod-eu"}},
+		{name: "one doesn't match", json: `["severity=\"critical\"", "namespace=~\"prod-.*\""]`, labels: map[string]string{"severity": "critical", "namespace": "dev"}, want: "namespace"},
+		{name: "first mismatch", json: `["severity=\"critical\"", "namespace=~\"prod-.*\""]`, labels: map[string]string{"severity": "warning", "namespace": "dev"}, want: "severity"},
+		// A missing label is an empty value
+		{name: "missing label", json: `["team!=\"infra\""]`, labels: map[string]string{}},
+		{name: "missing label required", json: `["team=~\".+\""]`, labels: map[string]string{}, want: "team"},
+		{name: "empty", json: `[]`, labels: map[string]string{"severity": "critical"}},
+		{name: "not a list", json: `"severity=\"critical\""`, wantErr: true},
+		{name: "invalid matcher", json: `["severity"]`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var ms Matchers
+			err := json.Unmarshal([]byte(tt.json), &ms)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("unmarshalling %s succeeded, want an error", tt.json)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unmarshalling %s: %v", tt.json, err)
+			}
+			var got string
+			if m := ms.Mismatch(tt.labels); m != nil {
+				got = m.Name
+			}
+			if got != tt.want {
+				t.Errorf("Mismatch(%v) = %q, want %q", tt.labels, got, tt.want)
+			}
+		})
+	}
+}
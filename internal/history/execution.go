@@ -0,0 +1,64 @@
+package history
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+type Status string
+
+const (
+	StatusQueued     Status = "queued"
+	StatusRunning    Status = "running"
+	StatusSucceeded  Status = "succeeded"
+	StatusFailed     Status = "failed"
+	StatusSuppressed Status = "suppressed"
+	// StatusCancelled executions were queued but dropped on shutdown
+	StatusCancelled Status = "cancelled"
+)
+
+// Trigger is what caused an execution
+type Trigger string
+
+const (
+	TriggerWebhook Trigger = "webhook"
+	TriggerManual  Trigger = "manual"
+)
+
+// Execution is the record of a rule's action being executed, or suppressed
+type Execution struct {
+	ID     string `json:"id"`
+	Rule   string `json:"rule"`
+	Action string `json:"action"`
+	// Trigger is empty in records written by older versions
+	Trigger Trigger `json:"trigger,omitempty"`
+	// RevertOf is the ID of the execution this execution reverts
+	RevertOf string `json:"revertOf,omitempty"`
+	// AlertName is the alertname label of the matched alert or group
+	AlertName    string            `json:"alertname,omitempty"`
+	GroupKey     string            `json:"groupKey,omitempty"`
+	Fingerprints []string          `json:"fingerprints,omitempty"`
+	Options      map[string]string `json:"options,omitempty"`
+	DryRun       bool              `json:"dryRun"`
+	Status       Status            `json:"status"`
+	Attempts     int               `json:"attempts"`
+	Error        string            `json:"error,omitempty"`
+	Output       string            `json:"output,omitempty"`
+	CreatedAt    time.Time         `json:"createdAt"`
+	StartedAt    *time.Time        `json:"startedAt,omitempty"`
+	EndedAt      *time.Time        `json:"endedAt,omitempty"`
+	// RevertState is the state a rule with revert_on_resolve restores when the alert resolves
+	RevertState map[string]string `json:"revertState,omitempty"`
+}
+
+// NewID returns a unique ID that sorts by creation time
+func NewID(createdAt time.Time) string {
+	suffix := make([]byte, 4)
+	_, err := rand.Read(suffix)
+	if err != nil {
+		panic(fmt.Sprintf("crypto/rand failure %v", err))
+	}
+	return fmt.Sprintf("%016x%s", createdAt.UnixNano(), hex.EncodeToString(suffix))
+}
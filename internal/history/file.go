@@ -0,0 +1,138 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// compactSlack is how many superseded lines the file may hold before it is compacted
+const compactSlack = 1000
+
+// FileStore persists executions to a JSON lines file. Every save appends
+// the execution, and the file is compacted to the latest version of each
+// execution on open and whenever it grows too large.
+// Reads are served from memory.
+type FileStore struct {
+	*MemoryStore
+	path  string
+	file  *os.File
+	lines int
+}
+
+func NewFileStore(path string, maxEntries int) (*FileStore, error) {
+	s := &FileStore{
+		MemoryStore: NewMemoryStore(maxEntries),
+		path:        path,
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	if err := s.compact(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileStore) load() error {
+	file, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		var execution Execution
+		if err := json.Unmarshal(scanner.Bytes(), &execution); err != nil {
+			// A crash may leave a partially written last line behind
+			slog.Warn("Skipping invalid history line", "file", s.path, "line", lineNum, "error", err.Error())
+			continue
+		}
+		s.save(&execution)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read history file: %w", err)
+	}
+	return nil
+}
+
+// compact rewrites the file with only the latest version of each execution.
+// Must be called with the lock held or before the store is in use.
+func (s *FileStore) compact() error {
+	tmpPath := s.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create history file: %w", err)
+	}
+	writer := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(writer)
+	for _, id := range s.order {
+		if err := encoder.Encode(s.executions[id]); err != nil {
+			tmp.Close()
+			return fmt.Errorf("failed to write history file: %w", err)
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write history file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write history file: %w", err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("failed to replace history file: %w", err)
+	}
+
+	if s.file != nil {
+		s.file.Close()
+	}
+	s.file, err = os.OpenFile(s.path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open history file: %w", err)
+	}
+	s.lines = len(s.order)
+	return nil
+}
+
+func (s *FileStore) Save(execution *Execution) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.save(clone(execution))
+
+	data, err := json.Marshal(execution)
+	if err != nil {
+		return fmt.Errorf("failed to encode execution: %w", err)
+	}
+	if _, err := s.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write history file: %w", err)
+	}
+	s.lines++
+
+	if s.lines > 2*len(s.order)+compactSlack {
+		return s.compact()
+	}
+	return nil
+}
+
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
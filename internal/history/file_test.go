@@ -0,0 +1,121 @@
+package history
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytes.Count(data, []byte("\n"))
+}
+
+func TestFileStoreCompaction(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	store, err := NewFileStore(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	execution := newTestExecution(time.Now())
+	// Every save appends a line until the superseded ones pass the slack
+	for attempt := 1; attempt <= compactSlack+2; attempt++ {
+		execution.Attempts = attempt
+		if err := store.Save(execution); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if lines := countLines(t, path); lines != compactSlack+2 {
+		t.Fatalf("history file has %d lines before compacting, want %d", lines, compactSlack+2)
+	}
+	execution.Attempts++
+	if err := store.Save(execution); err != nil {
+		t.Fatal(err)
+	}
+	if lines := countLines(t, path); lines != 1 {
+		t.Errorf("history file has %d lines after compacting, want 1", lines)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The latest version survives a reload
+	store, err = NewFileStore(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	got, err := store.Get(execution.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Attempts != execution.Attempts {
+		t.Errorf("reloaded %d attempts, want %d", got.Attempts, execution.Attempts)
+	}
+}
+
+func TestFileStoreTruncatedLastLine(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	store, err := NewFileStore(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	for i := range 2 {
+		if err := store.Save(newTestExecution(start.Add(time.Duration(i) * time.Second))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A crash left half of a line behind
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := file.WriteString(`{"id":"partial","rule":"r","sta`); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err = NewFileStore(path, 0)
+	if err != nil {
+		t.Fatalf("reloading after a truncated line: %v", err)
+	}
+	executions, _, err := store.List(&Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(executions) != 2 {
+		t.Fatalf("reloaded %d executions, want 2", len(executions))
+	}
+	// Later saves aren't appended to the partial line
+	if err := store.Save(newTestExecution(start.Add(2 * time.Second))); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+	store, err = NewFileStore(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if executions, _, _ := store.List(&Filter{}); len(executions) != 3 {
+		t.Errorf("reloaded %d executions after another save, want 3", len(executions))
+	}
+}
@@ -0,0 +1,39 @@
+package history
+
+import (
+	"time"
+)
+
+const (
+	DefaultListLimit = 50
+	MaxListLimit     = 500
+)
+
+// Filter selects executions to list. Empty fields match everything.
+type Filter struct {
+	Rule      string
+	Action    string
+	Status    Status
+	AlertName string
+	// Since and Until bound the creation time of the executions
+	Since time.Time
+	Until time.Time
+	// Cursor is the ID of the last execution of the previous page
+	Cursor string
+	Limit  int
+}
+
+func (f *Filter) Matches(execution *Execution) bool {
+	switch {
+	case f.Rule != "" && execution.Rule != f.Rule,
+		f.Action != "" && execution.Action != f.Action,
+		f.Status != "" && execution.Status != f.Status,
+		f.AlertName != "" && execution.AlertName != f.AlertName,
+		!f.Since.IsZero() && execution.CreatedAt.Before(f.Since),
+		!f.Until.IsZero() && !execution.CreatedAt.Before(f.Until),
+		// IDs sort by creation time and pages go from newest to oldest
+		f.Cursor != "" && execution.ID >= f.Cursor:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,52 @@
+package history
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFilterMatches(t *testing.T) {
+	t.Parallel()
+
+	createdAt := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	execution := &Execution{
+		ID:        NewID(createdAt),
+		Rule:      "r",
+		Action:    "ssh",
+		Status:    StatusFailed,
+		AlertName: "HighLoad",
+		CreatedAt: createdAt,
+	}
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{name: "empty", want: true},
+		{name: "rule", filter: Filter{Rule: "r"}, want: true},
+		{name: "other rule", filter: Filter{Rule: "other"}},
+		{name: "other action", filter: Filter{Action: "scale"}},
+		{name: "status", filter: Filter{Status: StatusFailed}, want: true},
+		{name: "other status", filter: Filter{Status: StatusSucceeded}},
+		{name: "other alertname", filter: Filter{AlertName: "DiskFull"}},
+		// Since is inclusive and Until exclusive
+		{name: "since the creation", filter: Filter{Since: createdAt}, want: true},
+		{name: "since after the creation", filter: Filter{Since: createdAt.Add(time.Nanosecond)}},
+		{name: "until the creation", filter: Filter{Until: createdAt}},
+		{name: "until after the creation", filter: Filter{Until: createdAt.Add(time.Nanosecond)}, want: true},
+		{name: "within bounds", filter: Filter{Since: createdAt.Add(-time.Hour), Until: createdAt.Add(time.Hour)}, want: true},
+		// Pages continue with executions older than the cursor
+		{name: "cursor of a newer execution", filter: Filter{Cursor: NewID(createdAt.Add(time.Second))}, want: true},
+		{name: "cursor of the execution", filter: Filter{Cursor: execution.ID}},
+		{name: "cursor of an older execution", filter: Filter{Cursor: NewID(createdAt.Add(-time.Second))}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.filter.Matches(execution); got != tt.want {
+				t.Errorf("Matches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
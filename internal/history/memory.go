@@ -0,0 +1,100 @@
+package history
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// MemoryStore keeps the most recent executions in memory
+type MemoryStore struct {
+	mu         sync.RWMutex
+	executions map[string]*Execution
+	// order of the execution IDs, oldest first
+	order      []string
+	maxEntries int
+}
+
+func NewMemoryStore(maxEntries int) *MemoryStore {
+	return &MemoryStore{
+		executions: make(map[string]*Execution),
+		maxEntries: maxEntries,
+	}
+}
+
+func clone(execution *Execution) *Execution {
+	c := *execution
+	c.Fingerprints = slices.Clone(execution.Fingerprints)
+	c.Options = maps.Clone(execution.Options)
+	return &c
+}
+
+func (s *MemoryStore) Save(execution *Execution) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.save(clone(execution))
+	return nil
+}
+
+func (s *MemoryStore) save(execution *Execution) {
+	if _, ok := s.executions[execution.ID]; !ok {
+		s.order = append(s.order, execution.ID)
+	}
+	s.executions[execution.ID] = execution
+
+	// Evict the oldest executions
+	if s.maxEntries > 0 && len(s.order) > s.maxEntries {
+		evicted := len(s.order) - s.maxEntries
+		for _, id := range s.order[:evicted] {
+			delete(s.executions, id)
+		}
+		s.order = slices.Delete(s.order, 0, evicted)
+	}
+}
+
+func (s *MemoryStore) Get(id string) (*Execution, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	execution, ok := s.executions[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return clone(execution), nil
+}
+
+func (s *MemoryStore) List(filter *Filter) ([]*Execution, string, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	limit = min(limit, MaxListLimit)
+
+	s.mu.RLock()
+	var matched []*Execution
+	for _, execution := range s.executions {
+		if filter.Matches(execution) {
+			matched = append(matched, execution)
+		}
+	}
+	s.mu.RUnlock()
+
+	slices.SortFunc(matched, func(a, b *Execution) int {
+		return strings.Compare(b.ID, a.ID)
+	})
+
+	var cursor string
+	if len(matched) > limit {
+		matched = matched[:limit]
+		cursor = matched[limit-1].ID
+	}
+	page := make([]*Execution, 0, len(matched))
+	for _, execution := range matched {
+		page = append(page, clone(execution))
+	}
+	return page, cursor, nil
+}
+
+func (s *MemoryStore) Close() error {
+	return nil
+}
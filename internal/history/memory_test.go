@@ -0,0 +1,109 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+func newTestExecution(createdAt time.Time) *Execution {
+	return &Execution{
+		ID:        NewID(createdAt),
+		Rule:      "r",
+		Action:    "ssh",
+		Status:    StatusSucceeded,
+		CreatedAt: createdAt,
+	}
+}
+
+func TestMemoryStoreList(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	store := NewMemoryStore(0)
+	var want []string
+	for i := range 7 {
+		execution := newTestExecution(start.Add(time.Duration(i) * time.Second))
+		if err := store.Save(execution); err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, execution.ID)
+	}
+	slices.Reverse(want)
+
+	tests := []struct {
+		limit int
+		pages []int
+	}{
+		{limit: 3, pages: []int{3, 3, 1}},
+		// A last page that is full has no cursor
+		{limit: 7, pages: []int{7}},
+		{limit: 10, pages: []int{7}},
+		// The default limit is used
+		{limit: 0, pages: []int{7}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.limit), func(t *testing.T) {
+			t.Parallel()
+
+			filter := &Filter{Limit: tt.limit}
+			var got []string
+			var pages []int
+			for {
+				executions, cursor, err := store.List(filter)
+				if err != nil {
+					t.Fatal(err)
+				}
+				pages = append(pages, len(executions))
+				for _, execution := range executions {
+					got = append(got, execution.ID)
+				}
+				if cursor == "" {
+					break
+				}
+				if len(pages) > len(want) {
+					t.Fatal("paging doesn't end")
+				}
+				filter.Cursor = cursor
+			}
+			if !slices.Equal(pages, tt.pages) {
+				t.Errorf("page sizes = %v, want %v", pages, tt.pages)
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("listed %v, want newest first %v", got, want)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreEviction(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	store := NewMemoryStore(2)
+	var ids []string
+	for i := range 3 {
+		execution := newTestExecution(start.Add(time.Duration(i) * time.Second))
+		if err := store.Save(execution); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, execution.ID)
+	}
+	// Saving an execution again doesn't evict anything
+	updated := newTestExecution(start)
+	updated.ID = ids[2]
+	if err := store.Save(updated); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Get(ids[0]); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get of the oldest execution error = %v, want %v", err, ErrNotFound)
+	}
+	for _, id := range ids[1:] {
+		if _, err := store.Get(id); err != nil {
+			t.Errorf("Get(%s): %v", id, err)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package history
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/USA-RedDragon/metrics-actioner/internal/config"
+)
+
+var ErrNotFound = errors.New("execution not found")
+
+// Store records executions
+type Store interface {
+	// Save creates or replaces an execution
+	Save(execution *Execution) error
+	// Get returns a copy of an execution, or ErrNotFound
+	Get(id string) (*Execution, error)
+	// List returns a page of the executions matching the filter, newest first,
+	// and the cursor of the next page, which is empty on the last page
+	List(filter *Filter) ([]*Execution, string, error)
+	Close() error
+}
+
+// NewStore creates the store configured by the history backend
+func NewStore(historyConfig *config.History) (Store, error) {
+	switch historyConfig.Backend {
+	case config.HistoryBackendMemory:
+		return NewMemoryStore(historyConfig.MaxEntries), nil
+	case config.HistoryBackendFile:
+		return NewFileStore(historyConfig.Path, historyConfig.MaxEntries)
+	}
+	return nil, fmt.Errorf("unknown history backend: %s", historyConfig.Backend)
+}
@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "metrics_actioner"
+
+//nolint:golint,gochecknoglobals
+var (
+	ActionAttempts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "action_attempts_total",
+		Help:      "Number of action execution attempts, including retries",
+	}, []string{"rule", "action", "result"})
+	ActionsSuppressed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "actions_suppressed_total",
+		Help:      "Number of matched actions that were not executed due to safety limits or deduplication",
+	}, []string{"rule", "action", "reason"})
+	RulesMatched = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rules_matched_total",
+		Help:      "Number of times a rule matched a webhook or alert",
+	}, []string{"rule", "action"})
+	ActionExecutions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "action_executions_total",
+		Help:      "Number of finished action executions by outcome",
+	}, []string{"rule", "action", "outcome"})
+	ActionExecutionDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "action_execution_duration_seconds",
+		Help:      "Duration of action executions, including retries",
+		Buckets:   []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+	}, []string{"rule", "action", "outcome"})
+	ActionLastSuccess = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "action_last_success_timestamp_seconds",
+		Help:      "Unix time of the last successful execution of a rule",
+	}, []string{"rule", "action"})
+	QueueDepth = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "queue_depth",
+		Help:      "Number of actions waiting for a worker",
+	})
+)
@@ -0,0 +1,15 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+//nolint:golint,gochecknoglobals
+var (
+	UnauthorizedRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_unauthorized_requests_total",
+		Help:      "Number of requests rejected for missing or invalid credentials",
+	}, []string{"route"})
+)
@@ -0,0 +1,20 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+//nolint:golint,gochecknoglobals
+var (
+	WebhooksReceived = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhooks_received_total",
+		Help:      "Number of AlertManager webhooks received",
+	}, []string{"receiver", "status"})
+	WebhookDecodeFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_decode_failures_total",
+		Help:      "Number of AlertManager webhooks that could not be decoded",
+	})
+)
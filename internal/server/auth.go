@@ -0,0 +1,131 @@
+package server
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/USA-RedDragon/metrics-actioner/internal/config"
+	"github.com/USA-RedDragon/metrics-actioner/internal/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+var errEmptySecret = errors.New("secret is empty")
+
+// requireAuth rejects requests that don't pass any of the configured
+// authentication methods. Requests pass through when none are configured.
+func requireAuth(authConfig *config.Auth) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !authConfig.Enabled() {
+			c.Next()
+			return
+		}
+		ok, err := authenticate(c.Request, authConfig)
+		if err != nil {
+			slog.Error("Failed to authenticate request", "path", c.Request.URL.Path, "error", err.Error())
+		}
+		if !ok {
+			metrics.UnauthorizedRequests.WithLabelValues(c.FullPath()).Inc()
+			if authConfig.BasicAuth.Username != "" {
+				c.Header("WWW-Authenticate", `Basic realm="metrics-actioner"`)
+			}
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// requireClientCert rejects requests without a verified client certificate
+// when the listener has client CAs
+func requireClientCert(tlsConfig *config.TLS) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if tlsConfig.ClientCAFile == "" {
+			c.Next()
+			return
+		}
+		if c.Request.TLS == nil || len(c.Request.TLS.VerifiedChains) == 0 {
+			metrics.UnauthorizedRequests.WithLabelValues(c.FullPath()).Inc()
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "client certificate required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+func authenticate(req *http.Request, authConfig *config.Auth) (bool, error) {
+	if authConfig.BearerToken != "" || authConfig.BearerTokenFile != "" {
+		token, err := readSecret(authConfig.BearerToken, authConfig.BearerTokenFile)
+		if err != nil {
+			return false, fmt.Errorf("failed to read bearer token: %w", err)
+		}
+		if given, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer "); ok && secretEqual(given, token) {
+			return true, nil
+		}
+	}
+
+	if authConfig.BasicAuth.Username != "" {
+		password, err := readSecret(authConfig.BasicAuth.Password, authConfig.BasicAuth.PasswordFile)
+		if err != nil {
+			return false, fmt.Errorf("failed to read basic auth password: %w", err)
+		}
+		username, givenPassword, ok := req.BasicAuth()
+		// Check both to not leak which one was wrong through timing
+		if ok && secretEqual(username, authConfig.BasicAuth.Username) && secretEqual(givenPassword, password) {
+			return true, nil
+		}
+	}
+
+	if authConfig.HMAC.Secret != "" || authConfig.HMAC.SecretFile != "" {
+		secret, err := readSecret(authConfig.HMAC.Secret, authConfig.HMAC.SecretFile)
+		if err != nil {
+			return false, fmt.Errorf("failed to read HMAC secret: %w", err)
+		}
+		signature, err := hex.DecodeString(strings.TrimPrefix(req.Header.Get(authConfig.HMAC.Header), "sha256="))
+		if err != nil || len(signature) == 0 {
+			return false, nil
+		}
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return false, fmt.Errorf("failed to read request body: %w", err)
+		}
+		// Leave the body for the handler
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		if hmac.Equal(signature, mac.Sum(nil)) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// readSecret returns the secret, read from the file if one is given.
+// An empty secret is an error, as anyone could authenticate with it.
+func readSecret(secret, file string) (string, error) {
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		secret = strings.TrimSpace(string(data))
+	}
+	if secret == "" {
+		return "", errEmptySecret
+	}
+	return secret, nil
+}
+
+func secretEqual(given, expected string) bool {
+	return subtle.ConstantTimeCompare([]byte(given), []byte(expected)) == 1
+}
@@ -0,0 +1,178 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/USA-RedDragon/metrics-actioner/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+const testBody = `{"status":"firing"}`
+
+func sign(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func writeSecret(t *testing.T, secret string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(file, []byte(secret), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestAuthenticate(t *testing.T) {
+	t.Parallel()
+
+	bearer := config.Auth{BearerToken: "token"}
+	basic := config.Auth{BasicAuth: config.BasicAuth{Username: "user", Password: "pass"}}
+	hmacAuth := config.Auth{HMAC: config.HMAC{Secret: "secret", Header: "X-Signature"}}
+	tests := []struct {
+		name    string
+		auth    config.Auth
+		headers map[string]string
+		user    string
+		pass    string
+		want    bool
+		wantErr error
+	}{
+		{name: "bearer", auth: bearer, headers: map[string]string{"Authorization": "Bearer token"}, want: true},
+		{name: "wrong bearer", auth: bearer, headers: map[string]string{"Authorization": "Bearer nope"}},
+		{name: "bearer without scheme", auth: bearer, headers: map[string]string{"Authorization": "token"}},
+		{name: "missing bearer", auth: bearer},
+		{name: "empty bearer token", auth: config.Auth{BearerTokenFile: "empty"}, headers: map[string]string{"Authorization": "Bearer "}, wantErr: errEmptySecret},
+		{name: "basic", auth: basic, user: "user", pass: "pass", want: true},
+		{name: "wrong password", auth: basic, user: "user", pass: "nope"},
+		{name: "wrong username", auth: basic, user: "nope", pass: "pass"},
+		{name: "basic with empty password", auth: config.Auth{BasicAuth: config.BasicAuth{Username: "user", PasswordFile: "empty"}}, user: "user", wantErr: errEmptySecret},
+		{name: "hmac", auth: hmacAuth, headers: map[string]string{"X-Signature": sign("secret", testBody)}, want: true},
+		{name: "hmac with prefix", auth: hmacAuth, headers: map[string]string{"X-Signature": "sha256=" + sign("secret", testBody)}, want: true},
+		{name: "hmac with another secret", auth: hmacAuth, headers: map[string]string{"X-Signature": sign("nope", testBody)}},
+		{name: "hmac of another body", auth: hmacAuth, headers: map[string]string{"X-Signature": sign("secret", "{}")}},
+		{name: "invalid hmac", auth: hmacAuth, headers: map[string]string{"X-Signature": "not hex"}},
+		{name: "missing hmac", auth: hmacAuth},
+		{name: "any method passes", auth: config.Auth{BearerToken: "token", BasicAuth: basic.BasicAuth}, user: "user", pass: "pass", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			auth := tt.auth
+			emptyFile := writeSecret(t, " \n")
+			if auth.BearerTokenFile == "empty" {
+				auth.BearerTokenFile = emptyFile
+			}
+			if auth.BasicAuth.PasswordFile == "empty" {
+				auth.BasicAuth.PasswordFile = emptyFile
+			}
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/webhooks/alertmanager", strings.NewReader(testBody))
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			if tt.user != "" {
+				req.SetBasicAuth(tt.user, tt.pass)
+			}
+
+			got, err := authenticate(req, &auth)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("authenticate error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("authenticate = %v, want %v", got, tt.want)
+			}
+			// The handler still gets the body
+			if body, _ := io.ReadAll(req.Body); string(body) != testBody {
+				t.Errorf("body after authenticating = %q, want %q", body, testBody)
+			}
+		})
+	}
+}
+
+func TestReadSecret(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		secret  string
+		file    string
+		want    string
+		wantErr bool
+	}{
+		{name: "inline", secret: "token", want: "token"},
+		{name: "file", file: "token\n", want: "token"},
+		{name: "empty", wantErr: true},
+		{name: "empty file", file: "\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var file string
+			if tt.file != "" {
+				file = writeSecret(t, tt.file)
+			}
+			got, err := readSecret(tt.secret, file)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readSecret error = %v, want an error: %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("readSecret = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		t.Parallel()
+
+		if _, err := readSecret("", filepath.Join(t.TempDir(), "missing")); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("readSecret error = %v, want %v", err, os.ErrNotExist)
+		}
+	})
+}
+
+func TestRequireAuth(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		auth          config.Auth
+		authorization string
+		want          int
+	}{
+		{name: "disabled", want: http.StatusOK},
+		{name: "authorized", auth: config.Auth{BearerToken: "token"}, authorization: "Bearer token", want: http.StatusOK},
+		{name: "unauthorized", auth: config.Auth{BearerToken: "token"}, authorization: "Bearer nope", want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			router := gin.New()
+			router.POST("/", requireAuth(&tt.auth), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(testBody))
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+			if recorder.Code != tt.want {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.want)
+			}
+		})
+	}
+}
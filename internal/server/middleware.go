@@ -5,19 +5,21 @@ import (
 
 	"github.com/USA-RedDragon/metrics-actioner/internal/alertmanager"
 	"github.com/USA-RedDragon/metrics-actioner/internal/config"
+	"github.com/USA-RedDragon/metrics-actioner/internal/history"
 	"github.com/gin-gonic/gin"
 	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 )
 
-func applyMiddleware(r *gin.Engine, config *config.HTTP, otelComponent string, receiver *alertmanager.Receiver) {
+func applyMiddleware(r *gin.Engine, config *config.HTTP, otelComponent string, receiver *alertmanager.Receiver, historyStore history.Store) {
 	r.Use(gin.Recovery())
 	r.Use(gin.Logger())
 	r.TrustedPlatform = "X-Real-IP"
 
 	if otelComponent == "api" {
 		r.Use(alertManagerReceiverProvider(receiver))
+		r.Use(historyStoreProvider(historyStore))
 	}
 
 	err := r.SetTrustedProxies(config.TrustedProxies)
@@ -53,3 +55,10 @@ func alertManagerReceiverProvider(receiver *alertmanager.Receiver) gin.HandlerFu
 		c.Next()
 	}
 }
+
+func historyStoreProvider(historyStore history.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("HistoryStore", historyStore)
+		c.Next()
+	}
+}
@@ -1,25 +1,44 @@
 package server
 
 import (
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/USA-RedDragon/metrics-actioner/internal/alertmanager"
 	"github.com/USA-RedDragon/metrics-actioner/internal/alertmanager/models"
+	"github.com/USA-RedDragon/metrics-actioner/internal/config"
+	"github.com/USA-RedDragon/metrics-actioner/internal/history"
+	"github.com/USA-RedDragon/metrics-actioner/internal/metrics"
 	"github.com/gin-gonic/gin"
 )
 
-func applyRoutes(r *gin.Engine) {
+func applyRoutes(r *gin.Engine, config *config.HTTP) {
 	r.GET("/health", func(c *gin.Context) {
 		c.String(http.StatusOK, "OK")
 	})
 
 	apiV1 := r.Group("/api/v1")
-	v1(apiV1)
+	v1(apiV1, config)
 }
 
-func v1(group *gin.RouterGroup) {
-	group.POST("/webhooks/alertmanager", v1ReceiveWebhook)
+func v1(group *gin.RouterGroup, config *config.HTTP) {
+	group.POST("/webhooks/alertmanager", requireClientCert(&config.TLS), requireAuth(&config.Auth), v1ReceiveWebhook)
+	// Executions hold the rendered options and output of actions
+	group.GET("/executions", requireClientCert(&config.TLS), requireAuth(&config.Auth), v1ListExecutions)
+	group.GET("/executions/:id", requireClientCert(&config.TLS), requireAuth(&config.Auth), v1GetExecution)
+
+	// Manual runs can execute arbitrary commands, so they're only available
+	// with their own credentials rather than the webhook's
+	if config.ManualRuns.Auth.Enabled() {
+		group.POST("/rules/:name/run", requireAuth(&config.ManualRuns.Auth), v1RunRule)
+		group.POST("/actions/:type/run", requireAuth(&config.ManualRuns.Auth), v1RunAction)
+	} else {
+		slog.Warn("Manual run endpoints are disabled until http.manual_runs.auth is configured")
+	}
 }
 
 func v1ReceiveWebhook(c *gin.Context) {
@@ -32,13 +51,159 @@ func v1ReceiveWebhook(c *gin.Context) {
 	}
 	if err := c.ShouldBindJSON(&json); err != nil {
 		slog.Error("Failed to bind AlertManager webhook JSON", "error", err.Error())
+		metrics.WebhookDecodeFailures.Inc()
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	if err := receiver.ReceiveWebhook(&json); err != nil {
+	if err := receiver.ReceiveWebhook(c.Request.Context(), &json); err != nil {
 		slog.Error("Failed to process AlertManager webhook", "error", err.Error())
+		if errors.Is(err, alertmanager.ErrQueueFull) || errors.Is(err, alertmanager.ErrQueueStopped) {
+			// Let AlertManager retry later
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"status": "accepted"})
+}
+
+func v1ListExecutions(c *gin.Context) {
+	historyStore, ok := c.MustGet("HistoryStore").(history.Store)
+	if !ok {
+		slog.Error("Failed to get history store from context")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	filter := history.Filter{
+		Rule:      c.Query("rule"),
+		Action:    c.Query("action"),
+		Status:    history.Status(c.Query("status")),
+		AlertName: c.Query("alertname"),
+		Cursor:    c.Query("cursor"),
+	}
+	var err error
+	if since := c.Query("since"); since != "" {
+		filter.Since, err = time.Parse(time.RFC3339, since)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid since, must be RFC3339"})
+			return
+		}
+	}
+	if until := c.Query("until"); until != "" {
+		filter.Until, err = time.Parse(time.RFC3339, until)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid until, must be RFC3339"})
+			return
+		}
+	}
+	if limit := c.Query("limit"); limit != "" {
+		filter.Limit, err = strconv.Atoi(limit)
+		if err != nil || filter.Limit < 1 || filter.Limit > history.MaxListLimit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid limit, must be between 1 and %d", history.MaxListLimit)})
+			return
+		}
+	}
+
+	executions, nextCursor, err := historyStore.List(&filter)
+	if err != nil {
+		slog.Error("Failed to list executions", "error", err.Error())
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"status": "success"})
+	c.JSON(http.StatusOK, gin.H{"executions": executions, "nextCursor": nextCursor})
+}
+
+func v1GetExecution(c *gin.Context) {
+	historyStore, ok := c.MustGet("HistoryStore").(history.Store)
+	if !ok {
+		slog.Error("Failed to get history store from context")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+
+	execution, err := historyStore.Get(c.Param("id"))
+	if errors.Is(err, history.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to get execution", "error", err.Error())
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, execution)
+}
+
+func v1RunRule(c *gin.Context) {
+	var run alertmanager.ManualRun
+	receiver, ok := c.MustGet("AlertManagerReceiver").(*alertmanager.Receiver)
+	if !ok {
+		slog.Error("Failed to get AlertManager receiver from context")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+	if err := c.ShouldBindJSON(&run); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if run.Options != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "options can't be set when running a rule"})
+		return
+	}
+	executions, err := receiver.RunRule(c.Request.Context(), c.Param("name"), &run)
+	if err != nil {
+		slog.Error("Failed to run rule", "rule", c.Param("name"), "error", err.Error())
+		response := gin.H{"error": err.Error()}
+		if len(executions) > 0 {
+			// Some alerts may have been queued before the queue filled up
+			response["executions"] = executions
+		}
+		c.JSON(manualRunErrorStatus(err), response)
+		return
+	}
+	slog.Info("Manually ran rule", "rule", c.Param("name"), "executions", len(executions))
+	c.JSON(http.StatusAccepted, gin.H{"executions": executions})
+}
+
+func v1RunAction(c *gin.Context) {
+	var run alertmanager.ManualRun
+	receiver, ok := c.MustGet("AlertManagerReceiver").(*alertmanager.Receiver)
+	if !ok {
+		slog.Error("Failed to get AlertManager receiver from context")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
+		return
+	}
+	if err := c.ShouldBindJSON(&run); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	execution, err := receiver.RunAction(c.Request.Context(), c.Param("type"), &run)
+	if err != nil {
+		slog.Error("Failed to run action", "action", c.Param("type"), "error", err.Error())
+		c.JSON(manualRunErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	slog.Info("Manually ran action", "action", c.Param("type"), "id", execution.ID)
+	c.JSON(http.StatusAccepted, gin.H{"executions": []*history.Execution{execution}})
+}
+
+func manualRunErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, alertmanager.ErrRuleNotFound), errors.Is(err, alertmanager.ErrActionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, alertmanager.ErrActionNotAllowed):
+		return http.StatusForbidden
+	case errors.Is(err, alertmanager.ErrInvalidRun):
+		return http.StatusBadRequest
+	case errors.Is(err, alertmanager.ErrNoMatch):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, alertmanager.ErrDuplicate):
+		return http.StatusConflict
+	case errors.Is(err, alertmanager.ErrQueueFull), errors.Is(err, alertmanager.ErrQueueStopped):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
 }
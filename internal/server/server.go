@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"log/slog"
 	"net"
@@ -11,6 +12,7 @@ import (
 
 	"github.com/USA-RedDragon/metrics-actioner/internal/alertmanager"
 	"github.com/USA-RedDragon/metrics-actioner/internal/config"
+	"github.com/USA-RedDragon/metrics-actioner/internal/history"
 	"github.com/gin-contrib/pprof"
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -28,7 +30,7 @@ type Server struct {
 
 const defTimeout = 5 * time.Second
 
-func NewServer(config *config.HTTP, receiver *alertmanager.Receiver) *Server {
+func NewServer(config *config.HTTP, receiver *alertmanager.Receiver, historyStore history.Store) (*Server, error) {
 	gin.SetMode(gin.ReleaseMode)
 	if config.PProf.Enabled {
 		gin.SetMode(gin.DebugMode)
@@ -45,15 +47,38 @@ func NewServer(config *config.HTTP, receiver *alertmanager.Receiver) *Server {
 		writeTimeout = 60 * time.Second
 	}
 
-	applyMiddleware(r, config, "api", receiver)
-	applyRoutes(r)
+	applyMiddleware(r, config, "api", receiver, historyStore)
+	applyRoutes(r, config)
+	if !config.Auth.Enabled() {
+		slog.Warn("API authentication is disabled, anyone able to reach the API can trigger actions and read the execution history")
+	}
+
+	var tlsConfig *tls.Config
+	if config.TLS.Enabled() {
+		var err error
+		// Health probes and API clients with their own credentials don't
+		// need a certificate, the webhook endpoint requires one
+		tlsConfig, err = newTLSConfig(&config.TLS, tls.VerifyClientCertIfGiven)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	var metricsIPV4Server *http.Server
 	var metricsIPV6Server *http.Server
 
 	if config.Metrics.Enabled {
+		var metricsTLSConfig *tls.Config
+		if config.Metrics.TLS.Enabled() {
+			var err error
+			metricsTLSConfig, err = newTLSConfig(&config.Metrics.TLS, tls.RequireAndVerifyClientCert)
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		metricsRouter := gin.New()
-		applyMiddleware(metricsRouter, config, "metrics", receiver)
+		applyMiddleware(metricsRouter, config, "metrics", receiver, historyStore)
 
 		metricsRouter.GET("/metrics", gin.WrapH(promhttp.Handler()))
 		metricsIPV4Server = &http.Server{
@@ -61,12 +86,14 @@ func NewServer(config *config.HTTP, receiver *alertmanager.Receiver) *Server {
 			ReadHeaderTimeout: defTimeout,
 			WriteTimeout:      writeTimeout,
 			Handler:           metricsRouter,
+			TLSConfig:         metricsTLSConfig,
 		}
 		metricsIPV6Server = &http.Server{
 			Addr:              fmt.Sprintf("[%s]:%d", config.Metrics.IPV6Host, config.Metrics.Port),
 			ReadHeaderTimeout: defTimeout,
 			WriteTimeout:      defTimeout,
 			Handler:           metricsRouter,
+			TLSConfig:         metricsTLSConfig,
 		}
 	}
 
@@ -76,17 +103,27 @@ func NewServer(config *config.HTTP, receiver *alertmanager.Receiver) *Server {
 			ReadHeaderTimeout: defTimeout,
 			WriteTimeout:      writeTimeout,
 			Handler:           r,
+			TLSConfig:         tlsConfig,
 		},
 		ipv6Server: &http.Server{
 			Addr:              fmt.Sprintf("[%s]:%d", config.IPV6Host, config.Port),
 			ReadHeaderTimeout: defTimeout,
 			WriteTimeout:      defTimeout,
 			Handler:           r,
+			TLSConfig:         tlsConfig,
 		},
 		metricsIPV4Server: metricsIPV4Server,
 		metricsIPV6Server: metricsIPV6Server,
 		config:            config,
+	}, nil
+}
+
+// serve serves the listener, over TLS if the server has a TLS config
+func serve(server *http.Server, listener net.Listener) error {
+	if server.TLSConfig != nil {
+		return server.ServeTLS(listener, "", "")
 	}
+	return server.Serve(listener)
 }
 
 func (s *Server) Start() error {
@@ -99,7 +136,7 @@ func (s *Server) Start() error {
 		waitGrp.Add(1)
 		go func() {
 			defer waitGrp.Done()
-			if err := s.ipv4Server.Serve(ipv4Listener); err != nil && !s.stopped {
+			if err := serve(s.ipv4Server, ipv4Listener); err != nil && !s.stopped {
 				slog.Error("HTTP IPv4 server error", "error", err.Error())
 			}
 		}()
@@ -113,12 +150,12 @@ func (s *Server) Start() error {
 		waitGrp.Add(1)
 		go func() {
 			defer waitGrp.Done()
-			if err := s.ipv6Server.Serve(ipv6Listener); err != nil && !s.stopped {
+			if err := serve(s.ipv6Server, ipv6Listener); err != nil && !s.stopped {
 				slog.Error("HTTP IPv6 server error", "error", err.Error())
 			}
 		}()
 	}
-	slog.Info("HTTP server started", "ipv4", s.config.IPV4Host, "ipv6", s.config.IPV6Host, "port", s.config.Port)
+	slog.Info("HTTP server started", "ipv4", s.config.IPV4Host, "ipv6", s.config.IPV6Host, "port", s.config.Port, "tls", s.config.TLS.Enabled())
 
 	if s.config.Metrics.Enabled {
 		if s.metricsIPV4Server != nil {
@@ -129,7 +166,7 @@ func (s *Server) Start() error {
 			waitGrp.Add(1)
 			go func() {
 				defer waitGrp.Done()
-				if err := s.metricsIPV4Server.Serve(metricsIPV4Listener); err != nil && !s.stopped {
+				if err := serve(s.metricsIPV4Server, metricsIPV4Listener); err != nil && !s.stopped {
 					slog.Error("Metrics IPv4 server error", "error", err.Error())
 				}
 			}()
@@ -143,12 +180,12 @@ func (s *Server) Start() error {
 			waitGrp.Add(1)
 			go func() {
 				defer waitGrp.Done()
-				if err := s.metricsIPV6Server.Serve(metricsIPV6Listener); err != nil && !s.stopped {
+				if err := serve(s.metricsIPV6Server, metricsIPV6Listener); err != nil && !s.stopped {
 					slog.Error("Metrics IPv6 server error", "error", err.Error())
 				}
 			}()
 		}
-		slog.Info("Metrics server started", "ipv4", s.config.Metrics.IPV4Host, "ipv6", s.config.Metrics.IPV6Host, "port", s.config.Metrics.Port)
+		slog.Info("Metrics server started", "ipv4", s.config.Metrics.IPV4Host, "ipv6", s.config.Metrics.IPV6Host, "port", s.config.Metrics.Port, "tls", s.config.Metrics.TLS.Enabled())
 	}
 
 	go func() {
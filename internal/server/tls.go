@@ -0,0 +1,117 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/USA-RedDragon/metrics-actioner/internal/config"
+)
+
+// certificateCheckInterval is how often handshakes check whether the
+// certificate files changed
+const certificateCheckInterval = 5 * time.Second
+
+// certificateReloader serves the TLS certificate and client CAs from files,
+// reloading them when the files change so rotated certificates, e.g. by
+// cert-manager, are picked up without a restart
+type certificateReloader struct {
+	config *config.TLS
+	// clientAuth is the client certificate policy when client CAs are set
+	clientAuth tls.ClientAuthType
+	mu         sync.Mutex
+	// fileState is the modification time and size of the files last loaded
+	fileState string
+	checkedAt time.Time
+	tlsConfig *tls.Config
+}
+
+// newTLSConfig returns a TLS config serving the configured certificate,
+// verifying client certificates with clientAuth when client CAs are set
+func newTLSConfig(tlsConfig *config.TLS, clientAuth tls.ClientAuthType) (*tls.Config, error) {
+	reloader := &certificateReloader{config: tlsConfig, clientAuth: clientAuth}
+	state, err := reloader.currentFileState()
+	if err != nil {
+		return nil, err
+	}
+	if err := reloader.load(state); err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		GetConfigForClient: reloader.getConfigForClient,
+	}, nil
+}
+
+func (r *certificateReloader) files() []string {
+	files := []string{r.config.CertFile, r.config.KeyFile}
+	if r.config.ClientCAFile != "" {
+		files = append(files, r.config.ClientCAFile)
+	}
+	return files
+}
+
+func (r *certificateReloader) currentFileState() (string, error) {
+	var state string
+	for _, file := range r.files() {
+		info, err := os.Stat(file)
+		if err != nil {
+			return "", err
+		}
+		state += fmt.Sprintf("%s:%d:%d;", file, info.ModTime().UnixNano(), info.Size())
+	}
+	return state, nil
+}
+
+func (r *certificateReloader) load(state string) error {
+	cert, err := tls.LoadX509KeyPair(r.config.CertFile, r.config.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{"h2", "http/1.1"},
+	}
+	if r.config.ClientCAFile != "" {
+		pool, err := config.LoadCertPool(r.config.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to load TLS client CAs: %w", err)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = r.clientAuth
+	}
+	r.tlsConfig = tlsConfig
+	r.fileState = state
+	r.checkedAt = time.Now()
+	return nil
+}
+
+func (r *certificateReloader) getConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.checkedAt) < certificateCheckInterval {
+		return r.tlsConfig, nil
+	}
+	r.checkedAt = time.Now()
+	state, err := r.currentFileState()
+	if err != nil {
+		// Files are briefly missing while being replaced
+		return r.tlsConfig, nil
+	}
+	if state != r.fileState {
+		start := time.Now()
+		if err := r.load(state); err != nil {
+			// Keep serving the old certificate, and don't retry until the files change again
+			slog.Error("Failed to reload TLS certificate, keeping the current one", "certFile", r.config.CertFile, "error", err.Error())
+			r.fileState = state
+		} else {
+			slog.Info("Reloaded TLS certificate", "certFile", r.config.CertFile, "duration", time.Since(start))
+		}
+	}
+	return r.tlsConfig, nil
+}
@@ -0,0 +1,87 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/USA-RedDragon/metrics-actioner/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const serviceName = "metrics-actioner"
+
+// Tracer returns the application's tracer for a component
+func Tracer(component string) trace.Tracer {
+	return otel.Tracer("github.com/USA-RedDragon/metrics-actioner/" + component)
+}
+
+// Setup installs the global tracer provider exporting spans to the OTLP endpoint.
+// The returned function flushes and stops the exporter.
+// The endpoint may be a host:port or a URL.
+func Setup(ctx context.Context, tracingConfig *config.Tracing, version string) (func(context.Context) error, error) {
+	endpoint := tracingConfig.OTLPEndpoint
+	isURL := strings.Contains(endpoint, "://")
+
+	var client otlptrace.Client
+	switch tracingConfig.OTLPProtocol {
+	case config.TracingProtocolHTTP:
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(endpoint)}
+		if isURL {
+			opts = []otlptracehttp.Option{otlptracehttp.WithEndpointURL(endpoint)}
+		}
+		if tracingConfig.OTLPInsecure {
+			opts = append(opts, otlptracehttp.WithInsecure())
+		}
+		client = otlptracehttp.NewClient(opts...)
+	case config.TracingProtocolGRPC:
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(endpoint)}
+		if isURL {
+			opts = []otlptracegrpc.Option{otlptracegrpc.WithEndpointURL(endpoint)}
+		}
+		if tracingConfig.OTLPInsecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		client = otlptracegrpc.NewClient(opts...)
+	default:
+		return nil, fmt.Errorf("unknown OTLP protocol: %s", tracingConfig.OTLPProtocol)
+	}
+
+	exporter, err := otlptrace.New(ctx, client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+		semconv.ServiceVersion(version),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tracing resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider.Shutdown, nil
+}
+
+// RecordError marks the span as failed with the error
+func RecordError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}